@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// checkpoint record the progress of a resumable transfer in a local file
+type checkpoint struct {
+	Bucket   string           `json:"bucket"`
+	Key      string           `json:"key"`
+	UploadID string           `json:"uploadId,omitempty"`
+	ETag     string           `json:"etag,omitempty"`
+	Size     int64            `json:"size"`
+	ModTime  time.Time        `json:"mtime"`
+	PartSize int64            `json:"partSize"`
+	Parts    map[int64]string `json:"parts"` // finished part number -> ETag
+
+	path string
+	mu   sync.Mutex
+}
+
+// loadCheckpoint load a checkpoint from path, return an empty one if path not exist
+func loadCheckpoint(path string) (*checkpoint, error) {
+	cp := &checkpoint{path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// match report whether the checkpoint was saved for the same transfer
+func (cp *checkpoint) match(bucket, key string, size int64, mtime time.Time) bool {
+	return cp.Bucket == bucket && cp.Key == key && cp.Size == size && cp.ModTime.Equal(mtime)
+}
+
+// reset start a new transfer in the checkpoint
+func (cp *checkpoint) reset(bucket, key string, size int64, mtime time.Time, partSize int64) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.Bucket = bucket
+	cp.Key = key
+	cp.UploadID = ""
+	cp.ETag = ""
+	cp.Size = size
+	cp.ModTime = mtime
+	cp.PartSize = partSize
+	cp.Parts = map[int64]string{}
+}
+
+// done record a finished part and save the checkpoint
+func (cp *checkpoint) done(num int64, etag string) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.Parts == nil {
+		cp.Parts = map[int64]string{}
+	}
+	cp.Parts[num] = etag
+	return cp.write()
+}
+
+// save write the checkpoint to its local file
+func (cp *checkpoint) save() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.write()
+}
+
+// write the checkpoint to a temp file and rename it, cp.mu must be held
+func (cp *checkpoint) write() error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(cp.path), filepath.Base(cp.path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), cp.path)
+}
+
+// remove the checkpoint file after the transfer finished
+func (cp *checkpoint) remove() error {
+	if err := os.Remove(cp.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
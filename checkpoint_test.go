@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_checkpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file"+putCheckpointSuffix)
+	cp, err := loadCheckpoint(path)
+	if err != nil {
+		t.Errorf("loadCheckpoint failed: %s", err)
+		return
+	}
+	mtime := time.Now()
+	if cp.match(testBucketName, testObjectKey, 1024, mtime) {
+		t.Errorf("empty checkpoint should not match")
+	}
+
+	cp.reset(testBucketName, testObjectKey, 1024, mtime, minPartSize)
+	cp.UploadID = "upload-id"
+	if err := cp.done(2, "etag2"); err != nil {
+		t.Errorf("checkpoint done failed: %s", err)
+		return
+	}
+
+	loaded, err := loadCheckpoint(path)
+	if err != nil {
+		t.Errorf("loadCheckpoint failed: %s", err)
+		return
+	}
+	if !loaded.match(testBucketName, testObjectKey, 1024, mtime) {
+		t.Errorf("loaded checkpoint does not match: %+v", loaded)
+	}
+	if loaded.UploadID != "upload-id" || loaded.PartSize != minPartSize || loaded.Parts[2] != "etag2" {
+		t.Errorf("unexpected loaded checkpoint: %+v", loaded)
+	}
+
+	if err := loaded.remove(); err != nil {
+		t.Errorf("checkpoint remove failed: %s", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint %s not removed", path)
+	}
+}
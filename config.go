@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// decodeConfigFile decode a JSON(starts with {) or YAML config file to v, unknown fields are
+// rejected, kind names the config in error message
+func decodeConfigFile(data []byte, v interface{}, kind string) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(v); err != nil {
+			return fmt.Errorf("invalid %s JSON: %w", kind, err)
+		}
+		return nil
+	}
+	if err := yaml.UnmarshalStrict(data, v); err != nil {
+		return fmt.Errorf("invalid %s YAML: %w", kind, err)
+	}
+	return nil
+}
+
+// loadConfigFile read and decode a JSON or YAML config file to v
+func loadConfigFile(filename string, v interface{}, kind string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return decodeConfigFile(data, v, kind)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_decodeConfigFile(t *testing.T) {
+	type rule struct {
+		ID     string            `json:"id" yaml:"id"`
+		Days   int64             `json:"days,omitempty" yaml:"days,omitempty"`
+		Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	}
+	type config struct {
+		Rules []rule `json:"rules" yaml:"rules"`
+	}
+	expect := config{Rules: []rule{{ID: "logs", Days: 30, Labels: map[string]string{"team": "data"}}}}
+	for _, data := range []string{
+		"rules:\n- id: logs\n  days: 30\n  labels: {team: data}\n",
+		` {"rules": [{"id": "logs", "days": 30, "labels": {"team": "data"}}]}`,
+	} {
+		cfg := config{}
+		if err := decodeConfigFile([]byte(data), &cfg, "test"); err != nil {
+			t.Errorf("decodeConfigFile failed: %s", err)
+		} else if !reflect.DeepEqual(cfg, expect) {
+			t.Errorf("expect: %+v, got: %+v", expect, cfg)
+		}
+	}
+
+	for data, format := range map[string]string{
+		"rules:\n- id: logs\n  day: 30\n":        "YAML",
+		`{"rules": [{"id": "logs", "day": 30}]}`: "JSON",
+		`{"rules": [`:                            "JSON",
+	} {
+		err := decodeConfigFile([]byte(data), &config{}, "test")
+		if err == nil || !strings.Contains(err.Error(), "invalid test "+format) {
+			t.Errorf("expect invalid test %s error for %s, got: %v", format, data, err)
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "test.yaml")
+	if err := ioutil.WriteFile(filename, []byte("rules:\n- id: logs\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+	cfg := config{}
+	if err := loadConfigFile(filename, &cfg, "test"); err != nil || len(cfg.Rules) != 1 {
+		t.Errorf("loadConfigFile got: %+v, %v", cfg, err)
+	}
+	if err := loadConfigFile(filename+".not-exist", &cfg, "test"); err == nil {
+		t.Errorf("expect error for not exist file")
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// maxCopySize max Object size of one CopyObject request
+const maxCopySize int64 = 5 << 30
+
+// copyThreshold return the Object size to copy with UploadPartCopy, at most maxCopySize
+func (sc *S3Cli) copyThreshold() int64 {
+	if sc.threshold <= 0 || sc.threshold > maxCopySize {
+		return maxCopySize
+	}
+	return sc.threshold
+}
+
+// copySource return the escaped copy source(x-amz-copy-source) of bucket/key
+func copySource(bucket, key string) string {
+	return (&url.URL{Path: bucket + "/" + key}).EscapedPath()
+}
+
+// headKey head a Object without output
+func (sc *S3Cli) headKey(bucket, key string) (*s3.HeadObjectOutput, error) {
+	algorithm, customerKey := sc.sse.customer()
+	return sc.headSSEC(bucket, key, algorithm, customerKey)
+}
+
+// headSource head a copy source Object with the copy source SSE-C key
+func (sc *S3Cli) headSource(bucket, key string) (*s3.HeadObjectOutput, error) {
+	algorithm, customerKey := sc.sse.copySource()
+	return sc.headSSEC(bucket, key, algorithm, customerKey)
+}
+
+// headSSEC head a Object with SSE-C algorithm and key(nil if not encrypted with SSE-C)
+func (sc *S3Cli) headSSEC(bucket, key string, algorithm, customerKey *string) (*s3.HeadObjectOutput, error) {
+	head, err := sc.Client.HeadObject(&s3.HeadObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		SSECustomerAlgorithm: algorithm,
+		SSECustomerKey:       customerKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("head %s/%s failed: %w", bucket, key, err)
+	}
+	return head, nil
+}
+
+// copyObjectInput return the CopyObject input of bucket/key to destBucket/destKey with SSE options
+func (sc *S3Cli) copyObjectInput(bucket, key, destBucket, destKey string) *s3.CopyObjectInput {
+	input := &s3.CopyObjectInput{
+		CopySource: aws.String(copySource(bucket, key)),
+		Bucket:     aws.String(destBucket),
+		Key:        aws.String(destKey),
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = sc.sse.encryption()
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	input.CopySourceSSECustomerAlgorithm, input.CopySourceSSECustomerKey = sc.sse.copySource()
+	return input
+}
+
+// printObjectWrite head the written Object and print it like copyObject
+func (sc *S3Cli) printObjectWrite(bucket, key string) error {
+	dest, err := sc.headKey(bucket, key)
+	if err != nil {
+		return err
+	}
+	out := objectWriteOutput{
+		Bucket:    bucket,
+		Key:       key,
+		ETag:      trimETag(dest.ETag),
+		VersionID: aws.StringValue(dest.VersionId),
+	}
+	return sc.printOutput(out, func() {
+		if sc.verbose {
+			fmt.Println(dest)
+		}
+	})
+}
+
+// copyMultipart server-side copy a large Object with parallel UploadPartCopy, keep its metadata and content type
+func (sc *S3Cli) copyMultipart(bucket, key, destBucket, destKey string, head *s3.HeadObjectOutput) error {
+	size := aws.Int64Value(head.ContentLength)
+	partSize := sc.mpuPartSize(size)
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:             aws.String(destBucket),
+		Key:                aws.String(destKey),
+		CacheControl:       head.CacheControl,
+		ContentDisposition: head.ContentDisposition,
+		ContentEncoding:    head.ContentEncoding,
+		ContentLanguage:    head.ContentLanguage,
+		ContentType:        head.ContentType,
+		Metadata:           sc.partSizeMetadata(head.Metadata, partSize),
+		StorageClass:       head.StorageClass,
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = sc.sse.encryption()
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	resp, err := sc.Client.CreateMultipartUpload(input)
+	if err != nil {
+		return fmt.Errorf("create multipart upload failed: %w", err)
+	}
+	uid := aws.StringValue(resp.UploadId)
+
+	n := int((size + partSize - 1) / partSize)
+	parts := make([]*s3.CompletedPart, n)
+	errs := make([]error, n)
+	parallel(sc.mpuConcurrency(), n, func(i int) {
+		start := int64(i) * partSize
+		end := start + partSize
+		if end > size {
+			end = size
+		}
+		num := int64(i + 1)
+		input := &s3.UploadPartCopyInput{
+			Bucket:            aws.String(destBucket),
+			Key:               aws.String(destKey),
+			CopySource:        aws.String(copySource(bucket, key)),
+			CopySourceIfMatch: head.ETag,
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", start, end-1)),
+			PartNumber:        aws.Int64(num),
+			UploadId:          aws.String(uid),
+		}
+		input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+		input.CopySourceSSECustomerAlgorithm, input.CopySourceSSECustomerKey = sc.sse.copySource()
+		r, err := sc.Client.UploadPartCopy(input)
+		if err != nil {
+			errs[i] = fmt.Errorf("upload part copy %d failed: %w", num, err)
+			return
+		}
+		sc.progress.add(end - start)
+		parts[i] = &s3.CompletedPart{ETag: r.CopyPartResult.ETag, PartNumber: aws.Int64(num)}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return sc.mpuCancel(destBucket, destKey, uid, err)
+		}
+	}
+	if err := sc.mpuFinish(destBucket, destKey, uid, parts); err != nil {
+		return sc.mpuCancel(destBucket, destKey, uid, err)
+	}
+	sc.progress.fileDone()
+	return nil
+}
+
+// copyKey server-side copy bucket/key(size bytes, unknown if < 0) to destBucket/destKey,
+// Objects not smaller than copyThreshold are copied with UploadPartCopy
+func (sc *S3Cli) copyKey(bucket, key, destBucket, destKey string, size int64) error {
+	if size < 0 || size >= sc.copyThreshold() {
+		head, err := sc.headSource(bucket, key)
+		if err != nil {
+			return err
+		}
+		if size = aws.Int64Value(head.ContentLength); size >= sc.copyThreshold() {
+			return sc.copyMultipart(bucket, key, destBucket, destKey, head)
+		}
+	}
+	_, err := sc.Client.CopyObject(sc.copyObjectInput(bucket, key, destBucket, destKey))
+	if err != nil {
+		return fmt.Errorf("copy object failed: %w", err)
+	}
+	sc.progress.add(size)
+	sc.progress.fileDone()
+	return nil
+}
+
+// verifyCopy check the copied destBucket/destKey against the source size and ETag,
+// ETags are only compared if both are single-part ETags of Objects not encrypted with SSE-KMS or SSE-C
+func (sc *S3Cli) verifyCopy(destBucket, destKey string, size int64, etag string) (*s3.HeadObjectOutput, error) {
+	head, err := sc.headKey(destBucket, destKey)
+	if err != nil {
+		return nil, err
+	}
+	if n := aws.Int64Value(head.ContentLength); n != size {
+		return nil, fmt.Errorf("verify %s/%s failed: size %d, expect %d", destBucket, destKey, n, size)
+	}
+	destETag := contentETag(head)
+	if etag != "" && destETag != "" && !strings.Contains(etag, "-") && !strings.Contains(destETag, "-") && destETag != etag {
+		return nil, fmt.Errorf("verify %s/%s failed: ETag %s, expect %s", destBucket, destKey, destETag, etag)
+	}
+	return head, nil
+}
+
+// moveKey copy bucket/key to destBucket/destKey, verify the destination and then delete the source
+func (sc *S3Cli) moveKey(bucket, key, destBucket, destKey string) (*s3.HeadObjectOutput, error) {
+	if bucket == destBucket && key == destKey {
+		return nil, fmt.Errorf("source and destination are the same: %s/%s", bucket, key)
+	}
+	head, err := sc.headSource(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	size := aws.Int64Value(head.ContentLength)
+	if sc.startProgress(size, 1) {
+		defer sc.stopProgress()
+	}
+	if size >= sc.copyThreshold() {
+		err = sc.copyMultipart(bucket, key, destBucket, destKey, head)
+	} else {
+		err = sc.copyKey(bucket, key, destBucket, destKey, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dest, err := sc.verifyCopy(destBucket, destKey, size, contentETag(head))
+	if err != nil {
+		return nil, err
+	}
+	_, err = sc.Client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("delete %s/%s failed: %w", bucket, key, err)
+	}
+	return dest, nil
+}
+
+// renameObject rename(move) source(bucket/key) to bucket/key
+func (sc *S3Cli) renameObject(source, bucket, key string) error {
+	srcBucket, srcKey := splitBucketObject(source)
+	head, err := sc.moveKey(srcBucket, srcKey, bucket, key)
+	if err != nil {
+		return err
+	}
+	out := objectWriteOutput{
+		Bucket:    bucket,
+		Key:       key,
+		ETag:      trimETag(head.ETag),
+		VersionID: aws.StringValue(head.VersionId),
+	}
+	return sc.printOutput(out, func() {
+		if sc.verbose {
+			fmt.Printf("%s -> %s/%s\n", source, bucket, key)
+		}
+	})
+}
+
+// listTransfers list all Objects with prefix(and match the glob pattern if not empty) and
+// map them to destBucket/destPrefix, the last path element of prefix is kept like localPath
+func (sc *S3Cli) listTransfers(bucket, prefix, pattern, destBucket, destPrefix string) ([]transferResult, error) {
+	results := []transferResult{}
+	walk := func(obj *s3.Object) error {
+		key := aws.StringValue(obj.Key)
+		results = append(results, transferResult{
+			source: fmt.Sprintf("%s/%s", bucket, key),
+			dest:   fmt.Sprintf("%s/%s%s", destBucket, destPrefix, key[strings.LastIndex(prefix, "/")+1:]),
+			size:   aws.Int64Value(obj.Size),
+		})
+		return nil
+	}
+	var err error
+	if pattern != "" {
+		err = sc.walkGlob(bucket, pattern, walk)
+	} else {
+		err = sc.walkObjects(bucket, prefix, walk)
+	}
+	return results, err
+}
+
+// copyObjects server-side copy all Objects with prefix(and match the glob pattern if not empty) to destBucket/destPrefix
+func (sc *S3Cli) copyObjects(bucket, prefix, pattern, destBucket, destPrefix string, jobs int) error {
+	results, err := sc.listTransfers(bucket, prefix, pattern, destBucket, destPrefix)
+	if err != nil {
+		return err
+	}
+	started := sc.startProgress(transferBytes(results))
+	parallel(jobs, len(results), func(i int) {
+		r := &results[i]
+		_, key := splitBucketObject(r.source)
+		_, destKey := splitBucketObject(r.dest)
+		r.err = sc.copyKey(bucket, key, destBucket, destKey, r.size)
+	})
+	if started {
+		sc.stopProgress()
+	}
+	return sc.printTransferSummary(results)
+}
+
+// moveObjects move all Objects with prefix to destBucket/destPrefix, both prefixes are
+// directories(end with /), so old does not move old-backup/ or older/
+func (sc *S3Cli) moveObjects(bucket, prefix, destBucket, destPrefix string, jobs int) error {
+	prefix, destPrefix = syncPrefix(prefix), syncPrefix(destPrefix)
+	results, err := sc.listTransfers(bucket, prefix, "", destBucket, destPrefix)
+	if err != nil {
+		return err
+	}
+	started := sc.startProgress(transferBytes(results))
+	parallel(jobs, len(results), func(i int) {
+		r := &results[i]
+		_, key := splitBucketObject(r.source)
+		_, destKey := splitBucketObject(r.dest)
+		_, r.err = sc.moveKey(bucket, key, destBucket, destKey)
+	})
+	if started {
+		sc.stopProgress()
+	}
+	return sc.printTransferSummary(results)
+}
+
+// streamKey copy src bucket/key(size bytes) to destBucket/destKey of sc, stream GET from src to PUT on sc
+func (sc *S3Cli) streamKey(src *S3Cli, bucket, key, destBucket, destKey string, size int64) error {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey = src.sse.customer()
+	resp, err := src.Client.GetObject(input)
+	if err != nil {
+		return fmt.Errorf("get %s/%s failed: %w", bucket, key, err)
+	}
+	defer resp.Body.Close()
+	if size < 0 {
+		size = aws.Int64Value(resp.ContentLength)
+	}
+	if sc.startProgress(size, 1) {
+		defer sc.stopProgress()
+	}
+	return sc.putReader(destBucket, destKey, src.verifyBody(bucket, key, resp), size, aws.StringValue(resp.ContentType), resp.Metadata)
+}
+
+// streamObject copy source(bucket/key) of src to bucket/key of sc, stream GET from src to PUT on sc
+func (sc *S3Cli) streamObject(src *S3Cli, source, bucket, key string) error {
+	srcBucket, srcKey := splitBucketObject(source)
+	if err := sc.streamKey(src, srcBucket, srcKey, bucket, key, -1); err != nil {
+		return err
+	}
+	return sc.printObjectWrite(bucket, key)
+}
+
+// streamObjects copy all Objects with prefix(and match the glob pattern if not empty) of src to
+// destBucket/destPrefix of sc, stream GET from src to PUT on sc
+func (sc *S3Cli) streamObjects(src *S3Cli, bucket, prefix, pattern, destBucket, destPrefix string, jobs int) error {
+	results, err := src.listTransfers(bucket, prefix, pattern, destBucket, destPrefix)
+	if err != nil {
+		return err
+	}
+	started := sc.startProgress(transferBytes(results))
+	parallel(jobs, len(results), func(i int) {
+		r := &results[i]
+		_, key := splitBucketObject(r.source)
+		_, destKey := splitBucketObject(r.dest)
+		r.err = sc.streamKey(src, bucket, key, destBucket, destKey, r.size)
+	})
+	if started {
+		sc.stopProgress()
+	}
+	return sc.printTransferSummary(results)
+}
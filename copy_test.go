@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func Test_copyObjectMultipart(t *testing.T) {
+	data := make([]byte, minPartSize+1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Errorf("copyObject rand failed: %s", err)
+		return
+	}
+	source := "testCopyMultipartSource"
+	meta := map[string]string{"Content-Type": "application/sql", "X-Amz-Meta-Owner": "dba"}
+	if _, err := s3Backend.PutObject(testBucketName, source, meta, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Errorf("copyObject backend PutObject failed: %s", err)
+		return
+	}
+
+	sc := s3cliTest
+	sc.threshold = 1 << 20
+	sc.partSize = minPartSize
+	dest := "testCopyMultipartDest"
+	if err := sc.copyObject(testBucketName+"/"+source, testBucketName, dest); err != nil {
+		t.Errorf("copyObject multipart failed: %s", err)
+		return
+	}
+	obj, err := s3Backend.GetObject(testBucketName, dest, nil)
+	if err != nil {
+		t.Errorf("copyObject backend GetObject failed: %s", err)
+		return
+	}
+	defer obj.Contents.Close()
+	got, err := ioutil.ReadAll(obj.Contents)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("copyObject multipart content mismatch: %d bytes, %v", len(got), err)
+	}
+	for k, v := range meta {
+		if obj.Metadata[k] != v {
+			t.Errorf("copyObject multipart metadata %s expect: %s, got: %s", k, v, obj.Metadata[k])
+		}
+	}
+}
+
+func Test_copyMultipartAbort(t *testing.T) {
+	data := make([]byte, minPartSize+1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("copyMultipartAbort rand failed: %s", err)
+	}
+	source := "testCopyMultipartAbortSource"
+	if _, err := s3Backend.PutObject(testBucketName, source, nil, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("copyMultipartAbort backend PutObject failed: %s", err)
+	}
+
+	sc := s3cliTest
+	sc.threshold = 1 << 20
+	sc.partSize = minPartSize
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	var uid string
+	client.Handlers.Sign.PushBack(func(r *request.Request) {
+		switch r.Operation.Name {
+		case "CompleteMultipartUpload":
+			r.Error = awserr.New("InternalError", "complete multipart upload failed", nil)
+		case "AbortMultipartUpload":
+			uid = aws.StringValue(r.Params.(*s3.AbortMultipartUploadInput).UploadId)
+		}
+	})
+	sc.Client = client
+	if err := sc.copyObject(testBucketName+"/"+source, testBucketName, "testCopyMultipartAbortDest"); err == nil {
+		t.Fatalf("expect copyObject multipart error")
+	}
+	if uid == "" {
+		t.Fatalf("expect the failed upload aborted")
+	}
+	if _, err := s3cliTest.mpuListParts(testBucketName, "testCopyMultipartAbortDest", uid); err == nil {
+		t.Errorf("expect UploadId %s aborted", uid)
+	}
+}
+
+func Test_copyThreshold(t *testing.T) {
+	sc := S3Cli{}
+	if n := sc.copyThreshold(); n != maxCopySize {
+		t.Errorf("expect: %d, got: %d", maxCopySize, n)
+	}
+	sc.threshold = 10 << 30
+	if n := sc.copyThreshold(); n != maxCopySize {
+		t.Errorf("expect: %d, got: %d", maxCopySize, n)
+	}
+	sc.threshold = 1 << 30
+	if n := sc.copyThreshold(); n != 1<<30 {
+		t.Errorf("expect: %d, got: %d", 1<<30, n)
+	}
+}
+
+func Test_copyObjects(t *testing.T) {
+	keys := []string{"file1", "sub/file2"}
+	for _, v := range keys {
+		if _, err := s3Backend.PutObject(testBucketName, "testCopyObjects/"+v, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+			t.Errorf("copyObjects backend PutObject failed: %s", err)
+			return
+		}
+	}
+	if err := s3cliTest.copyObjects(testBucketName, "testCopyObjects/", "", testBucketName, "testCopyObjectsDest/", 2); err != nil {
+		t.Errorf("copyObjects failed: %s", err)
+		return
+	}
+	src, err := newEndpointClient(&s3cliTest, s3cliTest.endpoint, "", "")
+	if err != nil {
+		t.Errorf("newEndpointClient failed: %s", err)
+		return
+	}
+	if err := s3cliTest.streamObjects(&S3Cli{Client: src}, testBucketName, "testCopyObjects/", "", testBucketName, "testStreamObjectsDest/", 2); err != nil {
+		t.Errorf("streamObjects failed: %s", err)
+		return
+	}
+	for _, v := range keys {
+		for _, prefix := range []string{"testCopyObjectsDest/", "testStreamObjectsDest/"} {
+			if _, err := s3Backend.HeadObject(testBucketName, prefix+v); err != nil {
+				t.Errorf("%s%s not copied: %s", prefix, v, err)
+			}
+		}
+	}
+}
+
+func Test_putReader(t *testing.T) {
+	data := make([]byte, minPartSize+100)
+	if _, err := rand.Read(data); err != nil {
+		t.Errorf("putReader rand failed: %s", err)
+		return
+	}
+	sc := s3cliTest
+	sc.partSize = minPartSize
+	sc.concurrency = 2
+	cases := map[string][]byte{
+		"testPutReaderEmpty": {},
+		"testPutReaderMPU":   data,
+	}
+	for key, v := range cases {
+		if err := sc.putReader(testBucketName, key, bytes.NewReader(v), -1, "", nil); err != nil {
+			t.Errorf("putReader %s failed: %s", key, err)
+			continue
+		}
+		obj, err := s3Backend.GetObject(testBucketName, key, nil)
+		if err != nil {
+			t.Errorf("putReader backend GetObject %s failed: %s", key, err)
+			continue
+		}
+		got, err := ioutil.ReadAll(obj.Contents)
+		obj.Contents.Close()
+		if err != nil || !bytes.Equal(got, v) {
+			t.Errorf("putReader %s content mismatch: %d bytes, %v", key, len(got), err)
+		}
+	}
+}
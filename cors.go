@@ -0,0 +1,365 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// maxCORSRules max rules of a Bucket CORS configuration
+	maxCORSRules = 100
+	// maxCORSRuleID max length of a CORS rule ID
+	maxCORSRuleID = 255
+	// errNoSuchCORS error code of a Bucket without CORS configuration
+	errNoSuchCORS = "NoSuchCORSConfiguration"
+)
+
+// corsMethods methods allowed in CORS rules
+var corsMethods = []string{"GET", "PUT", "POST", "DELETE", "HEAD"}
+
+// corsRule a readable CORS rule, also the schema of cors set file
+type corsRule struct {
+	ID             string   `json:"id,omitempty" yaml:"id,omitempty"`
+	AllowedOrigins []string `json:"allowedOrigins" yaml:"allowedOrigins"`
+	AllowedMethods []string `json:"allowedMethods" yaml:"allowedMethods"`
+	AllowedHeaders []string `json:"allowedHeaders,omitempty" yaml:"allowedHeaders,omitempty"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty" yaml:"exposeHeaders,omitempty"`
+	MaxAgeSeconds  int64    `json:"maxAgeSeconds,omitempty" yaml:"maxAgeSeconds,omitempty"`
+}
+
+// corsConfig the cors set file
+type corsConfig struct {
+	Rules []corsRule `json:"rules" yaml:"rules"`
+}
+
+// loadCORS read a JSON or YAML cors set file
+func loadCORS(filename string) ([]corsRule, error) {
+	cfg := corsConfig{}
+	if err := loadConfigFile(filename, &cfg, "CORS"); err != nil {
+		return nil, err
+	}
+	return cfg.Rules, nil
+}
+
+// validCORSRule check a CORS rule
+func validCORSRule(r corsRule) error {
+	if len(r.ID) > maxCORSRuleID {
+		return fmt.Errorf("ID longer than %d characters", maxCORSRuleID)
+	}
+	if len(r.AllowedOrigins) == 0 {
+		return errors.New("no allowed origin")
+	}
+	if len(r.AllowedMethods) == 0 {
+		return errors.New("no allowed method")
+	}
+	for _, m := range r.AllowedMethods {
+		valid := false
+		for _, v := range corsMethods {
+			valid = valid || m == v
+		}
+		if !valid {
+			return fmt.Errorf("invalid method %q(%s)", m, strings.Join(corsMethods, ", "))
+		}
+	}
+	for _, v := range append(append([]string{}, r.AllowedOrigins...), r.AllowedHeaders...) {
+		if strings.Count(v, "*") > 1 {
+			return fmt.Errorf("%q has more than one wildcard(*)", v)
+		}
+	}
+	if r.MaxAgeSeconds < 0 {
+		return errors.New("max age must not be negative")
+	}
+	return nil
+}
+
+// validCORS check CORS rules locally before PutBucketCors
+func validCORS(rules []corsRule) error {
+	if len(rules) == 0 {
+		return errors.New("no CORS rule")
+	}
+	if len(rules) > maxCORSRules {
+		return fmt.Errorf("too many CORS rules(> %d)", maxCORSRules)
+	}
+	for i, r := range rules {
+		if err := validCORSRule(r); err != nil {
+			return fmt.Errorf("invalid CORS rule %d(%s): %w", i+1, r.ID, err)
+		}
+	}
+	return nil
+}
+
+// toS3 convert a readable rule to s3.CORSRule
+func (r corsRule) toS3() *s3.CORSRule {
+	rule := &s3.CORSRule{
+		AllowedOrigins: aws.StringSlice(r.AllowedOrigins),
+		AllowedMethods: aws.StringSlice(r.AllowedMethods),
+	}
+	if r.ID != "" {
+		rule.ID = aws.String(r.ID)
+	}
+	if len(r.AllowedHeaders) > 0 {
+		rule.AllowedHeaders = aws.StringSlice(r.AllowedHeaders)
+	}
+	if len(r.ExposeHeaders) > 0 {
+		rule.ExposeHeaders = aws.StringSlice(r.ExposeHeaders)
+	}
+	if r.MaxAgeSeconds > 0 {
+		rule.MaxAgeSeconds = aws.Int64(r.MaxAgeSeconds)
+	}
+	return rule
+}
+
+// newCORSRule convert a s3.CORSRule to readable rule
+func newCORSRule(rule *s3.CORSRule) corsRule {
+	r := corsRule{
+		ID:             aws.StringValue(rule.ID),
+		AllowedOrigins: aws.StringValueSlice(rule.AllowedOrigins),
+		AllowedMethods: aws.StringValueSlice(rule.AllowedMethods),
+		MaxAgeSeconds:  aws.Int64Value(rule.MaxAgeSeconds),
+	}
+	if len(rule.AllowedHeaders) > 0 {
+		r.AllowedHeaders = aws.StringValueSlice(rule.AllowedHeaders)
+	}
+	if len(rule.ExposeHeaders) > 0 {
+		r.ExposeHeaders = aws.StringValueSlice(rule.ExposeHeaders)
+	}
+	return r
+}
+
+// corsName the name of the i-th(0 based) rule in explanations
+func corsName(i int, r corsRule) string {
+	if r.ID != "" {
+		return fmt.Sprintf("rule %d(%s)", i+1, r.ID)
+	}
+	return fmt.Sprintf("rule %d", i+1)
+}
+
+// corsMatch report whether s matches pattern with at most one wildcard(*)
+func corsMatch(pattern, s string) bool {
+	i := strings.Index(pattern, "*")
+	if i < 0 {
+		return pattern == s
+	}
+	return len(s) >= len(pattern)-1 && strings.HasPrefix(s, pattern[:i]) && strings.HasSuffix(s, pattern[i+1:])
+}
+
+// corsMatchAny return the first pattern matches s, case-insensitive if fold
+func corsMatchAny(patterns []string, s string, fold bool) (string, bool) {
+	for _, p := range patterns {
+		if fold && corsMatch(strings.ToLower(p), strings.ToLower(s)) || !fold && corsMatch(p, s) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// corsTest evaluate a preflight(OPTIONS) request of origin, method and request headers against
+// rules like S3 does: the first rule allows the origin, method and all headers is used
+func corsTest(rules []corsRule, origin, method string, headers []string) corsTestOutput {
+	out := corsTestOutput{Origin: origin, Method: method, Headers: headers, Reasons: []string{}}
+	if len(rules) == 0 {
+		out.Reasons = append(out.Reasons, "no CORS rule")
+		return out
+	}
+	for i, r := range rules {
+		name := corsName(i, r)
+		allowedOrigin, ok := corsMatchAny(r.AllowedOrigins, origin, false)
+		if !ok {
+			out.Reasons = append(out.Reasons, fmt.Sprintf("%s: origin %s not in %v", name, origin, r.AllowedOrigins))
+			continue
+		}
+		if _, ok := corsMatchAny(r.AllowedMethods, method, false); !ok {
+			out.Reasons = append(out.Reasons, fmt.Sprintf("%s: method %s not in %v", name, method, r.AllowedMethods))
+			continue
+		}
+		denied := ""
+		for _, h := range headers {
+			if _, ok := corsMatchAny(r.AllowedHeaders, h, true); !ok {
+				denied = h
+				break
+			}
+		}
+		if denied != "" {
+			out.Reasons = append(out.Reasons, fmt.Sprintf("%s: header %s not in %v", name, denied, r.AllowedHeaders))
+			continue
+		}
+		out.Reasons = append(out.Reasons, fmt.Sprintf("%s: matched origin %s, method %s", name, allowedOrigin, method))
+		out.Allowed = true
+		out.Rule = i + 1
+		out.RuleID = r.ID
+		out.Response = map[string]string{
+			"Access-Control-Allow-Origin":  origin,
+			"Access-Control-Allow-Methods": strings.Join(r.AllowedMethods, ", "),
+		}
+		if allowedOrigin == "*" {
+			out.Response["Access-Control-Allow-Origin"] = "*"
+		} else {
+			out.Response["Access-Control-Allow-Credentials"] = "true"
+			out.Response["Vary"] = "Origin, Access-Control-Request-Headers, Access-Control-Request-Method"
+		}
+		if len(headers) > 0 {
+			out.Response["Access-Control-Allow-Headers"] = strings.Join(headers, ", ")
+		}
+		if len(r.ExposeHeaders) > 0 {
+			out.Response["Access-Control-Expose-Headers"] = strings.Join(r.ExposeHeaders, ", ")
+		}
+		if r.MaxAgeSeconds > 0 {
+			out.Response["Access-Control-Max-Age"] = strconv.FormatInt(r.MaxAgeSeconds, 10)
+		}
+		break
+	}
+	return out
+}
+
+// getCORS get the CORS rules of a Bucket, empty if the Bucket has no CORS configuration
+func (sc *S3Cli) getCORS(bucket string) ([]corsRule, error) {
+	resp, err := sc.Client.GetBucketCors(&s3.GetBucketCorsInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errNoSuchCORS {
+			return []corsRule{}, nil
+		}
+		return nil, fmt.Errorf("get bucket cors failed: %w", err)
+	}
+	rules := make([]corsRule, 0, len(resp.CORSRules))
+	for _, rule := range resp.CORSRules {
+		rules = append(rules, newCORSRule(rule))
+	}
+	return rules, nil
+}
+
+// bucketCORSGet print the CORS rules of a Bucket
+func (sc *S3Cli) bucketCORSGet(bucket string) error {
+	if sc.presign {
+		req, _ := sc.Client.GetBucketCorsRequest(&s3.GetBucketCorsInput{
+			Bucket: aws.String(bucket),
+		})
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+	rules, err := sc.getCORS(bucket)
+	if err != nil {
+		return err
+	}
+	return sc.printOutput(corsOutput{Bucket: bucket, Rules: rules}, func() {
+		if len(rules) == 0 {
+			fmt.Printf("no CORS rule of %s\n", bucket)
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tORIGINS\tMETHODS\tHEADERS\tEXPOSE\tMAX-AGE")
+		for _, r := range rules {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", r.ID, strings.Join(r.AllowedOrigins, ","), strings.Join(r.AllowedMethods, ","),
+				strings.Join(r.AllowedHeaders, ","), strings.Join(r.ExposeHeaders, ","), r.MaxAgeSeconds)
+		}
+		w.Flush()
+	})
+}
+
+// bucketCORSSet validate and replace the CORS rules of a Bucket
+func (sc *S3Cli) bucketCORSSet(bucket string, rules []corsRule) error {
+	if err := validCORS(rules); err != nil {
+		return err
+	}
+	cfg := &s3.CORSConfiguration{}
+	for _, r := range rules {
+		cfg.CORSRules = append(cfg.CORSRules, r.toS3())
+	}
+	req, resp := sc.Client.PutBucketCorsRequest(&s3.PutBucketCorsInput{
+		Bucket:            aws.String(bucket),
+		CORSConfiguration: cfg,
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("put bucket cors failed: %w", err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// bucketCORSDelete delete the CORS configuration of a Bucket
+func (sc *S3Cli) bucketCORSDelete(bucket string) error {
+	req, resp := sc.Client.DeleteBucketCorsRequest(&s3.DeleteBucketCorsInput{
+		Bucket: aws.String(bucket),
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("delete bucket cors failed: %w", err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// bucketCORSTest evaluate a preflight request against the CORS rules of a Bucket(or the rules
+// file if not empty) locally, explain which rule matched or why none did
+func (sc *S3Cli) bucketCORSTest(bucket, filename, origin, method string, headers []string) error {
+	var rules []corsRule
+	var err error
+	if filename != "" {
+		rules, err = loadCORS(filename)
+	} else {
+		rules, err = sc.getCORS(bucket)
+	}
+	if err != nil {
+		return err
+	}
+	out := corsTest(rules, origin, strings.ToUpper(method), headers)
+	out.Bucket = bucket
+	err = sc.printOutput(out, func() {
+		for _, r := range out.Reasons {
+			fmt.Println(r)
+		}
+		if !out.Allowed {
+			return
+		}
+		fmt.Printf("allowed by %s\n", corsName(out.Rule-1, rules[out.Rule-1]))
+		keys := make([]string, 0, len(out.Response))
+		for k := range out.Response {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("%s: %s\n", k, out.Response[k])
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !out.Allowed {
+		return fmt.Errorf("preflight %s from %s is not allowed", out.Method, origin)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_validCORS(t *testing.T) {
+	valid := corsRule{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}}
+	if err := validCORS([]corsRule{valid}); err != nil {
+		t.Errorf("expect valid rule, got: %s", err)
+	}
+	if err := validCORS(nil); err == nil {
+		t.Errorf("expect error for no rule")
+	}
+	for _, r := range []corsRule{
+		{AllowedMethods: []string{"GET"}},
+		{AllowedOrigins: []string{"*"}},
+		{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"OPTIONS"}},
+		{AllowedOrigins: []string{"https://*.*.com"}, AllowedMethods: []string{"GET"}},
+		{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}, AllowedHeaders: []string{"x-*-*"}},
+		{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}, MaxAgeSeconds: -1},
+		{ID: strings.Repeat("a", maxCORSRuleID+1), AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}},
+	} {
+		if err := validCORS([]corsRule{r}); err == nil {
+			t.Errorf("expect error for rule: %+v", r)
+		}
+	}
+}
+
+func Test_corsRuleS3(t *testing.T) {
+	r := corsRule{
+		ID:             "web",
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedMethods: []string{"GET"},
+		AllowedHeaders: []string{"Content-Type"},
+		ExposeHeaders:  []string{"ETag"},
+		MaxAgeSeconds:  60,
+	}
+	if got := newCORSRule(r.toS3()); !reflect.DeepEqual(got, r) {
+		t.Errorf("expect: %+v, got: %+v", r, got)
+	}
+}
+
+func Test_corsMatch(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		match      bool
+	}{
+		{"*", "https://example.com", true},
+		{"https://example.com", "https://example.com", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://*.example.com", "https://www.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"x-amz-*", "x-amz-meta-a", true},
+		{"ab*ba", "aba", false},
+	}
+	for _, c := range cases {
+		if got := corsMatch(c.pattern, c.s); got != c.match {
+			t.Errorf("corsMatch(%s, %s) expect: %v, got: %v", c.pattern, c.s, c.match, got)
+		}
+	}
+}
+
+func Test_corsTest(t *testing.T) {
+	rules := []corsRule{
+		{ID: "read", AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET", "HEAD"}},
+		{ID: "upload", AllowedOrigins: []string{"https://*.example.com"}, AllowedMethods: []string{"PUT"},
+			AllowedHeaders: []string{"content-type", "x-amz-*"}, ExposeHeaders: []string{"ETag"}, MaxAgeSeconds: 600},
+	}
+
+	out := corsTest(rules, "https://any.org", "GET", nil)
+	if !out.Allowed || out.Rule != 1 || out.Response["Access-Control-Allow-Origin"] != "*" {
+		t.Errorf("expect allowed by rule 1, got: %+v", out)
+	}
+
+	out = corsTest(rules, "https://www.example.com", "PUT", []string{"Content-Type", "X-Amz-Meta-A"})
+	if !out.Allowed || out.RuleID != "upload" {
+		t.Fatalf("expect allowed by rule upload, got: %+v", out)
+	}
+	expect := map[string]string{
+		"Access-Control-Allow-Origin":      "https://www.example.com",
+		"Access-Control-Allow-Methods":     "PUT",
+		"Access-Control-Allow-Headers":     "Content-Type, X-Amz-Meta-A",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Expose-Headers":    "ETag",
+		"Access-Control-Max-Age":           "600",
+		"Vary":                             "Origin, Access-Control-Request-Headers, Access-Control-Request-Method",
+	}
+	if !reflect.DeepEqual(out.Response, expect) {
+		t.Errorf("expect response: %v, got: %v", expect, out.Response)
+	}
+	if len(out.Reasons) != 2 || !strings.Contains(out.Reasons[0], "method PUT not in") {
+		t.Errorf("expect rule 1 method mismatch reason, got: %v", out.Reasons)
+	}
+
+	out = corsTest(rules, "https://www.example.com", "PUT", []string{"Authorization"})
+	if out.Allowed || len(out.Reasons) != 2 || !strings.Contains(out.Reasons[1], "header Authorization not in") {
+		t.Errorf("expect denied by header, got: %+v", out)
+	}
+	out = corsTest(rules, "https://example.com", "PUT", nil)
+	if out.Allowed || !strings.Contains(out.Reasons[1], "origin https://example.com not in") {
+		t.Errorf("expect denied by origin, got: %+v", out)
+	}
+	if out = corsTest(nil, "https://example.com", "GET", nil); out.Allowed || len(out.Reasons) != 1 {
+		t.Errorf("expect denied without rule, got: %+v", out)
+	}
+}
+
+func Test_bucketCORSTestFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cors.yaml")
+	data := "rules:\n- allowedOrigins: [https://example.com]\n  allowedMethods: [GET]\n"
+	if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+	sc := s3cliTest
+	sc.output = outputJSON
+	if err := sc.bucketCORSTest(testBucketName, filename, "https://example.com", "get", nil); err != nil {
+		t.Errorf("expect preflight allowed, got: %s", err)
+	}
+	if err := sc.bucketCORSTest(testBucketName, filename, "https://example.org", "GET", nil); err == nil {
+		t.Errorf("expect preflight not allowed")
+	}
+}
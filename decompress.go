@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compression formats of cat --decompress
+const (
+	formatGzip  = "gzip"
+	formatZstd  = "zstd"
+	formatBzip2 = "bzip2"
+)
+
+// compressionMagics the leading bytes of compression formats
+var compressionMagics = []struct {
+	format string
+	magic  []byte
+}{
+	{formatGzip, []byte{0x1f, 0x8b}},
+	{formatZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{formatBzip2, []byte("BZh")},
+}
+
+// detectCompression return the compression format detected from the magic bytes of head, or "" if
+// head is not compressed. Content-Encoding is not trusted, the HTTP client may already decode it
+func detectCompression(head []byte) string {
+	for _, v := range compressionMagics {
+		if bytes.HasPrefix(head, v.magic) {
+			return v.format
+		}
+	}
+	return ""
+}
+
+// decompressReader return a reader of the decompressed r, r is returned as is if it is not compressed
+func decompressReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch detectCompression(head) {
+	case formatGzip:
+		return gzip.NewReader(br)
+	case formatZstd:
+		d, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	case formatBzip2:
+		return ioutil.NopCloser(bzip2.NewReader(br)), nil
+	}
+	return ioutil.NopCloser(br), nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func Test_detectCompression(t *testing.T) {
+	cases := []struct {
+		head   []byte
+		expect string
+	}{
+		{[]byte{0x1f, 0x8b, 0x08, 0x00}, formatGzip},
+		{[]byte{0x28, 0xb5, 0x2f, 0xfd}, formatZstd},
+		{[]byte("BZh9"), formatBzip2},
+		{[]byte("text"), ""},
+		{nil, ""},
+	}
+	for i, v := range cases {
+		if got := detectCompression(v.head); got != v.expect {
+			t.Errorf("case %d expect: %q, got: %q", i, v.expect, got)
+		}
+	}
+}
+
+func Test_decompressReader(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(testObjectContent)
+	gw.Close()
+
+	var zs bytes.Buffer
+	zw, err := zstd.NewWriter(&zs)
+	if err != nil {
+		t.Fatalf("zstd NewWriter failed: %s", err)
+	}
+	zw.Write(testObjectContent)
+	zw.Close()
+
+	cases := map[string][]byte{
+		"gzip":  gz.Bytes(),
+		"zstd":  zs.Bytes(),
+		"plain": testObjectContent,
+		"empty": nil,
+	}
+	for name, data := range cases {
+		r, err := decompressReader(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("decompressReader %s failed: %s", name, err)
+			continue
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Errorf("decompressReader %s read failed: %s", name, err)
+		} else if expect := testObjectContent; name != "empty" && !bytes.Equal(got, expect) {
+			t.Errorf("decompressReader %s expect: %s, got: %s", name, expect, got)
+		} else if name == "empty" && len(got) != 0 {
+			t.Errorf("decompressReader empty got: %s", got)
+		}
+	}
+}
+
+func Test_catObjectDecompress(t *testing.T) {
+	key := "testCatObjectDecompress.gz"
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(testObjectContent)
+	gw.Close()
+	if _, err := s3Backend.PutObject(testBucketName, key, nil, bytes.NewReader(gz.Bytes()), int64(gz.Len())); err != nil {
+		t.Fatalf("decompress backend PutObject failed: %s", err)
+	}
+	if err := s3cliTest.catObject(testBucketName, key, "", "", true); err != nil {
+		t.Errorf("catObject decompress failed: %s", err)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// defaultStorageClass storage class of Objects listed without one
+const defaultStorageClass = "STANDARD"
+
+// duUsage the total size and count of Objects
+type duUsage struct {
+	size              int64
+	objects           int64
+	noncurrentSize    int64
+	noncurrentObjects int64
+}
+
+// add an Object(version) to the usage
+func (u *duUsage) add(size int64, current bool) {
+	u.size += size
+	u.objects++
+	if !current {
+		u.noncurrentSize += size
+		u.noncurrentObjects++
+	}
+}
+
+// duCounter summarize Objects under prefix, per common prefix(up to depth) and per storage class
+type duCounter struct {
+	prefix   string
+	depth    int
+	total    duUsage
+	prefixes map[string]*duUsage
+	classes  map[string]*duUsage
+}
+
+// newDuCounter create a duCounter of prefix
+func newDuCounter(prefix string, depth int) *duCounter {
+	return &duCounter{
+		prefix:   prefix,
+		depth:    depth,
+		prefixes: map[string]*duUsage{},
+		classes:  map[string]*duUsage{},
+	}
+}
+
+// duPrefixes return the common prefixes of key up to depth levels under prefix, like du --max-depth
+func duPrefixes(prefix, key string, depth int) []string {
+	var prefixes []string
+	if !strings.HasPrefix(key, prefix) {
+		return prefixes
+	}
+	end := len(prefix)
+	for i := 0; i < depth; i++ {
+		n := strings.Index(key[end:], "/")
+		if n < 0 {
+			break
+		}
+		end += n + 1
+		prefixes = append(prefixes, key[:end])
+	}
+	return prefixes
+}
+
+// add an Object(version) to the counter
+func (c *duCounter) add(key string, size int64, storageClass string, current bool) {
+	c.total.add(size, current)
+	if storageClass == "" {
+		storageClass = defaultStorageClass
+	}
+	if c.classes[storageClass] == nil {
+		c.classes[storageClass] = &duUsage{}
+	}
+	c.classes[storageClass].add(size, current)
+	for _, p := range duPrefixes(c.prefix, key, c.depth) {
+		if c.prefixes[p] == nil {
+			c.prefixes[p] = &duUsage{}
+		}
+		c.prefixes[p].add(size, current)
+	}
+}
+
+// output convert the counter to du output
+func (c *duCounter) output(bucket string, versions bool) duOutput {
+	out := duOutput{
+		Bucket:         bucket,
+		Prefix:         c.prefix,
+		Versions:       versions,
+		duUsageOutput:  newDuUsageOutput(c.total, versions),
+		StorageClasses: []duClassOutput{},
+	}
+	for _, k := range sortedKeys(c.classes) {
+		out.StorageClasses = append(out.StorageClasses, duClassOutput{
+			StorageClass:  k,
+			duUsageOutput: newDuUsageOutput(*c.classes[k], versions),
+		})
+	}
+	if c.depth > 0 {
+		out.Prefixes = []duPrefixOutput{}
+	}
+	for _, k := range sortedKeys(c.prefixes) {
+		out.Prefixes = append(out.Prefixes, duPrefixOutput{
+			Prefix:        k,
+			duUsageOutput: newDuUsageOutput(*c.prefixes[k], versions),
+		})
+	}
+	return out
+}
+
+// sortedKeys return the sorted keys of m
+func sortedKeys(m map[string]*duUsage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newDuUsageOutput convert a usage, noncurrent versions are only shown with versions
+func newDuUsageOutput(u duUsage, versions bool) duUsageOutput {
+	out := duUsageOutput{Size: u.size, Objects: u.objects}
+	if versions {
+		out.NoncurrentSize = u.noncurrentSize
+		out.NoncurrentObjects = u.noncurrentObjects
+	}
+	return out
+}
+
+// walkVersions list all Object versions with prefix page by page and call fn for each version
+func (sc *S3Cli) walkVersions(bucket, prefix string, fn func(v *s3.ObjectVersion) error) error {
+	var fnErr error
+	err := sc.Client.ListObjectVersionsPages(&s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(p *s3.ListObjectVersionsOutput, last bool) (shouldContinue bool) {
+		for _, v := range p.Versions {
+			if fnErr = fn(v); fnErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("list object versions failed: %w", err)
+	}
+	return fnErr
+}
+
+// du summarize the size and count of Objects with prefix
+func (sc *S3Cli) du(bucket, prefix string, depth int, versions, human bool) error {
+	c := newDuCounter(prefix, depth)
+	var err error
+	if versions {
+		err = sc.walkVersions(bucket, prefix, func(v *s3.ObjectVersion) error {
+			c.add(aws.StringValue(v.Key), aws.Int64Value(v.Size), aws.StringValue(v.StorageClass), aws.BoolValue(v.IsLatest))
+			return nil
+		})
+	} else {
+		err = sc.walkObjects(bucket, prefix, func(obj *s3.Object) error {
+			c.add(aws.StringValue(obj.Key), aws.Int64Value(obj.Size), aws.StringValue(obj.StorageClass), true)
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	out := c.output(bucket, versions)
+	return sc.printOutput(out, func() {
+		size := func(n int64) string {
+			if human {
+				return humanSize(n)
+			}
+			return strconv.FormatInt(n, 10)
+		}
+		line := func(w *tabwriter.Writer, u duUsageOutput, name string) {
+			if versions {
+				fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\n", size(u.Size), u.Objects, size(u.NoncurrentSize), u.NoncurrentObjects, name)
+			} else {
+				fmt.Fprintf(w, "%s\t%d\t%s\n", size(u.Size), u.Objects, name)
+			}
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, p := range out.Prefixes {
+			line(w, p.duUsageOutput, bucket+"/"+p.Prefix)
+		}
+		for _, class := range out.StorageClasses {
+			line(w, class.duUsageOutput, class.StorageClass)
+		}
+		line(w, out.duUsageOutput, strings.TrimSuffix(bucket+"/"+prefix, "/")+" (total)")
+		w.Flush()
+	})
+}
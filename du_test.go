@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func Test_duPrefixes(t *testing.T) {
+	cases := []struct {
+		prefix, key string
+		depth       int
+		expect      []string
+	}{
+		{"logs/", "logs/2024/01/app.log", 1, []string{"logs/2024/"}},
+		{"logs/", "logs/2024/01/app.log", 3, []string{"logs/2024/", "logs/2024/01/"}},
+		{"logs/", "logs/app.log", 1, nil},
+		{"", "logs/app.log", 1, []string{"logs/"}},
+		{"logs/", "logs/2024/app.log", 0, nil},
+	}
+	for i, v := range cases {
+		if got := duPrefixes(v.prefix, v.key, v.depth); !reflect.DeepEqual(got, v.expect) {
+			t.Errorf("case %d expect: %v, got: %v", i, v.expect, got)
+		}
+	}
+}
+
+func Test_duCounter(t *testing.T) {
+	c := newDuCounter("logs/", 1)
+	c.add("logs/a/1", 10, "", true)
+	c.add("logs/a/1", 5, "", false)
+	c.add("logs/b/1", 20, "GLACIER", true)
+	c.add("logs/1", 1, "", true)
+
+	out := c.output(testBucketName, true)
+	if out.Size != 36 || out.Objects != 4 || out.NoncurrentSize != 5 || out.NoncurrentObjects != 1 {
+		t.Errorf("unexpected total: %+v", out.duUsageOutput)
+	}
+	if len(out.Prefixes) != 2 || out.Prefixes[0].Prefix != "logs/a/" || out.Prefixes[0].Size != 15 || out.Prefixes[1].Size != 20 {
+		t.Errorf("unexpected prefixes: %+v", out.Prefixes)
+	}
+	if len(out.StorageClasses) != 2 || out.StorageClasses[0].StorageClass != "GLACIER" || out.StorageClasses[1].StorageClass != defaultStorageClass || out.StorageClasses[1].Size != 16 {
+		t.Errorf("unexpected storage classes: %+v", out.StorageClasses)
+	}
+
+	if out := c.output(testBucketName, false); out.NoncurrentSize != 0 {
+		t.Errorf("noncurrent size without versions: %+v", out.duUsageOutput)
+	}
+}
+
+func Test_du(t *testing.T) {
+	prefix := "testDu/"
+	for _, v := range []string{"file1", "sub/file2", "sub/subsub/file3"} {
+		if _, err := s3Backend.PutObject(testBucketName, prefix+v, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+			t.Errorf("du backend PutObject failed: %s", err)
+			return
+		}
+	}
+	for _, output := range []string{outputText, outputJSON} {
+		sc := s3cliTest
+		sc.output = output
+		if err := sc.du(testBucketName, prefix, 2, false, true); err != nil {
+			t.Errorf("du %s output failed: %s", output, err)
+		}
+	}
+}
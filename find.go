@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// actions of find --exec
+const (
+	findExecDelete = "delete"
+	findExecCopy   = "copy"
+	findExecTag    = "tag"
+)
+
+// findOptions filter Objects and the action on matched Objects
+type findOptions struct {
+	name       string         // shell pattern to match the base name of key
+	regex      *regexp.Regexp // regexp to match the key
+	minSize    int64
+	maxSize    int64         // no limit if < 0
+	olderThan  time.Duration // modified before now-olderThan if > 0
+	newerThan  time.Duration // modified after now-newerThan if > 0
+	exec       string        // delete, copy, tag or empty
+	destBucket string        // copy destination
+	destPrefix string        // copy destination prefix, replace the find prefix
+	tags       []*s3.Tag     // tags to set
+	jobs       int           // number of parallel copy/tag
+}
+
+// validFindExec check the --exec flag value
+func validFindExec(exec string) error {
+	switch exec {
+	case "", findExecDelete, findExecCopy, findExecTag:
+		return nil
+	}
+	return fmt.Errorf("invalid exec: %s(delete, copy, tag)", exec)
+}
+
+// match report whether obj matches all the filters
+func (opt *findOptions) match(obj *s3.Object, now time.Time) bool {
+	key := aws.StringValue(obj.Key)
+	size := aws.Int64Value(obj.Size)
+	mtime := aws.TimeValue(obj.LastModified)
+	if opt.name != "" {
+		if ok, _ := path.Match(opt.name, path.Base(key)); !ok {
+			return false
+		}
+	}
+	if opt.regex != nil && !opt.regex.MatchString(key) {
+		return false
+	}
+	if size < opt.minSize || (opt.maxSize >= 0 && size > opt.maxSize) {
+		return false
+	}
+	if opt.olderThan > 0 && mtime.After(now.Add(-opt.olderThan)) {
+		return false
+	}
+	if opt.newerThan > 0 && mtime.Before(now.Add(-opt.newerThan)) {
+		return false
+	}
+	return true
+}
+
+// findExec run the --exec action on matched Objects, and return the error of each Object
+func (sc *S3Cli) findExec(bucket, prefix string, objects []*s3.Object, opt findOptions) []error {
+	errs := make([]error, len(objects))
+	switch opt.exec {
+	case findExecDelete:
+		keys := make([]string, len(objects))
+		for i, obj := range objects {
+			keys[i] = aws.StringValue(obj.Key)
+		}
+		failed, err := sc.deleteKeys(bucket, keys)
+		for i, key := range keys {
+			if err != nil {
+				errs[i] = err
+			} else {
+				errs[i] = failed[key]
+			}
+		}
+	case findExecCopy:
+		parallel(opt.jobs, len(objects), func(i int) {
+			key := aws.StringValue(objects[i].Key)
+			errs[i] = sc.copyKey(bucket, key, opt.destBucket, opt.destPrefix+key[len(prefix):], aws.Int64Value(objects[i].Size))
+		})
+	case findExecTag:
+		parallel(opt.jobs, len(objects), func(i int) {
+			errs[i] = sc.tagKey(bucket, aws.StringValue(objects[i].Key), tagOpSet, opt.tags)
+		})
+	}
+	return errs
+}
+
+// find list Objects with prefix, print the matched ones and run --exec action on them
+func (sc *S3Cli) find(bucket, prefix string, opt findOptions) error {
+	now := time.Now()
+	out := findOutput{
+		Bucket:  bucket,
+		Prefix:  prefix,
+		Exec:    opt.exec,
+		Objects: []findObjectOutput{},
+	}
+	var batch []*s3.Object
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		errs := sc.findExec(bucket, prefix, batch, opt)
+		for i, obj := range batch {
+			o := findObjectOutput{objectOutput: newObjectOutput(obj)}
+			if opt.exec != "" {
+				o.Status = "ok"
+				if errs[i] != nil {
+					o.Status = "failed"
+					o.Error = errs[i].Error()
+					out.Failed++
+				}
+			}
+			if sc.textOutput() {
+				if errs[i] != nil {
+					fmt.Fprintf(os.Stderr, "%s %s failed: %s\n", opt.exec, o.Key, errs[i])
+				} else {
+					fmt.Println(o.Key)
+				}
+			} else {
+				out.Objects = append(out.Objects, o)
+			}
+		}
+		batch = batch[:0]
+	}
+
+	err := sc.walkObjects(bucket, prefix, func(obj *s3.Object) error {
+		if !opt.match(obj, now) {
+			return nil
+		}
+		out.Matched++
+		batch = append(batch, obj)
+		// print matched Objects at once if no action
+		if opt.exec == "" || len(batch) >= maxDeleteKeys {
+			flush()
+		}
+		return nil
+	})
+	flush()
+	if err != nil {
+		return err
+	}
+	if err := sc.printOutput(out, func() {}); err != nil {
+		return err
+	}
+	if out.Failed > 0 {
+		return fmt.Errorf("%s %d of %d Objects failed", opt.exec, out.Failed, out.Matched)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func Test_findOptionsMatch(t *testing.T) {
+	now := time.Now()
+	obj := &s3.Object{
+		Key:          aws.String("data/2024/part-0001.parquet"),
+		Size:         aws.Int64(2 << 20),
+		LastModified: aws.Time(now.Add(-10 * 24 * time.Hour)),
+	}
+	cases := []struct {
+		opt    findOptions
+		expect bool
+	}{
+		{findOptions{maxSize: -1}, true},
+		{findOptions{name: "*.parquet", maxSize: -1}, true},
+		{findOptions{name: "*.csv", maxSize: -1}, false},
+		{findOptions{regex: regexp.MustCompile(`^data/2024/`), maxSize: -1}, true},
+		{findOptions{regex: regexp.MustCompile(`^data/2023/`), maxSize: -1}, false},
+		{findOptions{minSize: 1 << 20, maxSize: 1 << 30}, true},
+		{findOptions{minSize: 3 << 20, maxSize: -1}, false},
+		{findOptions{maxSize: 1 << 20}, false},
+		{findOptions{olderThan: 7 * 24 * time.Hour, maxSize: -1}, true},
+		{findOptions{olderThan: 30 * 24 * time.Hour, maxSize: -1}, false},
+		{findOptions{newerThan: 30 * 24 * time.Hour, maxSize: -1}, true},
+		{findOptions{newerThan: 2 * 24 * time.Hour, maxSize: -1}, false},
+	}
+	for i, v := range cases {
+		if got := v.opt.match(obj, now); got != v.expect {
+			t.Errorf("case %d expect: %v, got: %v", i, v.expect, got)
+		}
+	}
+}
+
+func Test_find(t *testing.T) {
+	prefix := "testFind/"
+	for _, v := range []string{"a.csv", "sub/b.csv", "sub/c.txt"} {
+		if _, err := s3Backend.PutObject(testBucketName, prefix+v, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+			t.Errorf("find backend PutObject failed: %s", err)
+			return
+		}
+	}
+
+	opt := findOptions{name: "*.csv", maxSize: -1, exec: findExecCopy, destBucket: testBucketName, destPrefix: "testFindCopy/", jobs: 2}
+	if err := s3cliTest.find(testBucketName, prefix, opt); err != nil {
+		t.Errorf("find copy failed: %s", err)
+		return
+	}
+	for _, v := range []string{"a.csv", "sub/b.csv"} {
+		if _, err := s3Backend.HeadObject(testBucketName, "testFindCopy/"+v); err != nil {
+			t.Errorf("find did not copy %s: %s", v, err)
+		}
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, "testFindCopy/sub/c.txt"); err == nil {
+		t.Errorf("find copied unmatched sub/c.txt")
+	}
+
+	opt = findOptions{name: "*.txt", maxSize: -1, exec: findExecDelete}
+	if err := s3cliTest.find(testBucketName, prefix, opt); err != nil {
+		t.Errorf("find delete failed: %s", err)
+		return
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, prefix+"sub/c.txt"); err == nil {
+		t.Errorf("find did not delete sub/c.txt")
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, prefix+"a.csv"); err != nil {
+		t.Errorf("find deleted unmatched a.csv")
+	}
+}
+
+func Test_findNoMatch(t *testing.T) {
+	sc := s3cliTest
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	deletes := 0
+	client.Handlers.Send.PushFront(func(r *request.Request) {
+		if r.Operation.Name == "DeleteObjects" {
+			deletes++
+		}
+	})
+	sc.Client = client
+	opt := findOptions{name: "*.not-exist", maxSize: -1, exec: findExecDelete}
+	if err := sc.find(testBucketName, "", opt); err != nil {
+		t.Errorf("find delete failed: %s", err)
+	}
+	if deletes != 0 {
+		t.Errorf("expect no DeleteObjects without matched Objects, got: %d", deletes)
+	}
+}
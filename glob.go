@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// splitGlob return the longest literal(unescaped) prefix of a shell pattern,
+// and whether the pattern has any wildcard(*, ? or [). A key without wildcard
+// is returned with \*, \? and \[ unescaped.
+func splitGlob(pattern string) (prefix string, glob bool) {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*', '?', '[':
+			return sb.String(), true
+		case '\\':
+			if i+1 < len(pattern) && strings.IndexByte(`*?[\`, pattern[i+1]) >= 0 {
+				i++
+			}
+			sb.WriteByte(pattern[i])
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), false
+}
+
+// splitKeyGlob splitGlob a key, but a key with wildcard is not a pattern if the Object
+// named exactly key exists(head with the SSE-C key of head)
+func (sc *S3Cli) splitKeyGlob(bucket, key string, head func(bucket, key string) (*s3.HeadObjectOutput, error)) (prefix string, glob bool) {
+	prefix, glob = splitGlob(key)
+	if !glob || sc.presign {
+		return prefix, glob
+	}
+	if _, err := head(bucket, key); err == nil {
+		return key, false
+	}
+	return prefix, glob
+}
+
+// validGlob check the syntax of a shell pattern
+func validGlob(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	return nil
+}
+
+// walkGlob list Objects with the literal prefix of pattern and call fn for each
+// Object matches the pattern, * and ? do not match /
+func (sc *S3Cli) walkGlob(bucket, pattern string, fn func(obj *s3.Object) error) error {
+	if err := validGlob(pattern); err != nil {
+		return err
+	}
+	prefix, _ := splitGlob(pattern)
+	matched := 0
+	err := sc.walkObjects(bucket, prefix, func(obj *s3.Object) error {
+		if ok, _ := path.Match(pattern, aws.StringValue(obj.Key)); !ok {
+			return nil
+		}
+		matched++
+		return fn(obj)
+	})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return fmt.Errorf("no Object matches %s/%s", bucket, pattern)
+	}
+	return nil
+}
+
+// globKeys return the keys of Objects match the pattern
+func (sc *S3Cli) globKeys(bucket, pattern string) ([]string, error) {
+	keys := []string{}
+	err := sc.walkGlob(bucket, pattern, func(obj *s3.Object) error {
+		keys = append(keys, aws.StringValue(obj.Key))
+		return nil
+	})
+	return keys, err
+}
+
+// deleteGlob delete all Objects match the pattern
+func (sc *S3Cli) deleteGlob(bucket, pattern string) error {
+	keys, err := sc.globKeys(bucket, pattern)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for start := 0; start < len(keys); start += maxDeleteKeys {
+		end := start + maxDeleteKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+		errs, err := sc.deleteKeys(bucket, keys[start:end])
+		if err != nil {
+			return err
+		}
+		for _, key := range keys[start:end] {
+			if e := errs[key]; e != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "delete %s failed: %s\n", key, e)
+			} else if sc.verbose && sc.textOutput() {
+				fmt.Println(key)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("delete %d of %d Objects failed", failed, len(keys))
+	}
+	return nil
+}
+
+// catGlob print the contents of all Objects match the pattern
+func (sc *S3Cli) catGlob(bucket, pattern, oRange string, decompress bool) error {
+	keys, err := sc.globKeys(bucket, pattern)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := sc.catObject(bucket, key, oRange, "", decompress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
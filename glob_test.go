@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func Test_splitGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		prefix  string
+		glob    bool
+	}{
+		{"logs/2024-*/app-?.log", "logs/2024-", true},
+		{"logs/app-?.log", "logs/app-", true},
+		{"logs/[ab].log", "logs/", true},
+		{"*.log", "", true},
+		{"logs/app.log", "logs/app.log", false},
+		{`logs/app\*.log`, "logs/app*.log", false},
+		{`logs/a\b\*/*.log`, `logs/a\b*/`, true},
+	}
+	for i, v := range cases {
+		prefix, glob := splitGlob(v.pattern)
+		if prefix != v.prefix || glob != v.glob {
+			t.Errorf("case %d expect: %s %v, got: %s %v", i, v.prefix, v.glob, prefix, glob)
+		}
+	}
+}
+
+func Test_glob(t *testing.T) {
+	prefix := "testGlob/"
+	keys := []string{"2024-01/app-1.log", "2024-02/app-2.log", "2024-02/app-10.log", "2023-12/app-1.log"}
+	for _, v := range keys {
+		if _, err := s3Backend.PutObject(testBucketName, prefix+v, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+			t.Errorf("glob backend PutObject failed: %s", err)
+			return
+		}
+	}
+	pattern := prefix + "2024-*/app-?.log"
+	matched, err := s3cliTest.globKeys(testBucketName, pattern)
+	if err != nil || len(matched) != 2 {
+		t.Errorf("globKeys expect 2 keys, got: %v, %v", matched, err)
+	}
+	if _, err := s3cliTest.globKeys(testBucketName, prefix+"2022-*"); err == nil {
+		t.Errorf("expect error if no Object matches")
+	}
+
+	dir := t.TempDir()
+	if err := s3cliTest.getObjects(testBucketName, prefix+"2024-", pattern, dir, 2, false); err != nil {
+		t.Errorf("getObjects pattern failed: %s", err)
+		return
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "2024-01", "app-1.log"))
+	if err != nil || !bytes.Equal(data, testObjectContent) {
+		t.Errorf("getObjects pattern unexpected file: %s, %v", data, err)
+	}
+
+	if err := s3cliTest.copyObjects(testBucketName, prefix+"2024-", pattern, testBucketName, "testGlobCopy/", 2); err != nil {
+		t.Errorf("copyObjects pattern failed: %s", err)
+		return
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, "testGlobCopy/2024-02/app-2.log"); err != nil {
+		t.Errorf("copyObjects pattern did not copy: %s", err)
+	}
+
+	if err := s3cliTest.deleteGlob(testBucketName, pattern); err != nil {
+		t.Errorf("deleteGlob failed: %s", err)
+		return
+	}
+	for i, v := range keys {
+		_, err := s3Backend.HeadObject(testBucketName, prefix+v)
+		if deleted := err != nil; deleted != (i < 2) {
+			t.Errorf("deleteGlob %s deleted: %v", v, deleted)
+		}
+	}
+}
+
+func Test_splitKeyGlob(t *testing.T) {
+	prefix := "testGlobLiteral/"
+	for _, v := range []string{"foo[1].txt", "foo1.txt"} {
+		if _, err := s3Backend.PutObject(testBucketName, prefix+v, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+			t.Fatalf("splitKeyGlob backend PutObject failed: %s", err)
+		}
+	}
+	sc := s3cliTest
+	key := prefix + "foo[1].txt"
+	if got, glob := sc.splitKeyGlob(testBucketName, key, sc.headKey); glob || got != key {
+		t.Errorf("expect literal key %s, got: %s %v", key, got, glob)
+	}
+	if got, glob := sc.splitKeyGlob(testBucketName, prefix+"foo[2].txt", sc.headKey); !glob || got != prefix+"foo" {
+		t.Errorf("expect pattern with prefix %sfoo, got: %s %v", prefix, got, glob)
+	}
+
+	if err := sc.deleteObject(testBucketName, key, ""); err != nil {
+		t.Fatalf("deleteObject failed: %s", err)
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, key); err == nil {
+		t.Errorf("deleteObject did not delete %s", key)
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, prefix+"foo1.txt"); err != nil {
+		t.Errorf("deleteObject deleted %sfoo1.txt", prefix)
+	}
+}
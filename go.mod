@@ -5,6 +5,13 @@ go 1.17
 require (
 	github.com/aws/aws-sdk-go v1.40.59
 	github.com/johannesboyne/gofakes3 v0.0.0-20210819161434-5c8dfcfe5310
+	github.com/klauspost/compress v1.15.15
 	github.com/spf13/cobra v1.2.1
+	gopkg.in/yaml.v2 v2.4.0
+)
 
+require (
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/ryszard/goskiplist v0.0.0-20150312221310-2dfbae5fcf46 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
 )
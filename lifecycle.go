@@ -0,0 +1,503 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// maxLifecycleRules max rules of a Bucket lifecycle configuration
+	maxLifecycleRules = 1000
+	// maxLifecycleRuleID max length of a lifecycle rule ID
+	maxLifecycleRuleID = 255
+	// lifecycleDateLayout layout of expiration/transition dates(midnight UTC)
+	lifecycleDateLayout = "2006-01-02"
+	// minIATransitionDays min days to transition to STANDARD_IA or ONEZONE_IA
+	minIATransitionDays = 30
+	// errNoSuchLifecycle error code of a Bucket without lifecycle configuration
+	errNoSuchLifecycle = "NoSuchLifecycleConfiguration"
+)
+
+// lifecycleTransition transition Objects to a storage class after days(or on date)
+type lifecycleTransition struct {
+	Days         int64  `json:"days,omitempty" yaml:"days,omitempty"`
+	Date         string `json:"date,omitempty" yaml:"date,omitempty"`
+	StorageClass string `json:"storageClass" yaml:"storageClass"`
+}
+
+// lifecycleRule a readable lifecycle rule, also the schema of lifecycle set file
+type lifecycleRule struct {
+	ID                        string                `json:"id,omitempty" yaml:"id,omitempty"`
+	Status                    string                `json:"status" yaml:"status"`
+	Prefix                    string                `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Tags                      map[string]string     `json:"tags,omitempty" yaml:"tags,omitempty"`
+	ExpirationDays            int64                 `json:"expirationDays,omitempty" yaml:"expirationDays,omitempty"`
+	ExpirationDate            string                `json:"expirationDate,omitempty" yaml:"expirationDate,omitempty"`
+	ExpiredObjectDeleteMarker bool                  `json:"expiredObjectDeleteMarker,omitempty" yaml:"expiredObjectDeleteMarker,omitempty"`
+	NoncurrentExpirationDays  int64                 `json:"noncurrentExpirationDays,omitempty" yaml:"noncurrentExpirationDays,omitempty"`
+	AbortIncompleteUploadDays int64                 `json:"abortIncompleteUploadDays,omitempty" yaml:"abortIncompleteUploadDays,omitempty"`
+	Transitions               []lifecycleTransition `json:"transitions,omitempty" yaml:"transitions,omitempty"`
+	NoncurrentTransitions     []lifecycleTransition `json:"noncurrentTransitions,omitempty" yaml:"noncurrentTransitions,omitempty"`
+}
+
+// lifecycleConfig the lifecycle set file
+type lifecycleConfig struct {
+	Rules []lifecycleRule `json:"rules" yaml:"rules"`
+}
+
+// loadLifecycle read a JSON or YAML lifecycle set file
+func loadLifecycle(filename string) ([]lifecycleRule, error) {
+	cfg := lifecycleConfig{}
+	if err := loadConfigFile(filename, &cfg, "lifecycle"); err != nil {
+		return nil, err
+	}
+	return cfg.Rules, nil
+}
+
+// parseTransition parse a transition like 30:STANDARD_IA or 2030-01-01:GLACIER
+func parseTransition(s string) (lifecycleTransition, error) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 || i == len(s)-1 {
+		return lifecycleTransition{}, fmt.Errorf("invalid transition %q, expect <days|date>:<storage-class>", s)
+	}
+	t := lifecycleTransition{StorageClass: strings.ToUpper(s[i+1:])}
+	if days, err := strconv.ParseInt(s[:i], 10, 64); err == nil {
+		t.Days = days
+	} else {
+		t.Date = s[:i]
+	}
+	return t, nil
+}
+
+// validLifecycleDate check a date of lifecycle rule
+func validLifecycleDate(date string) error {
+	if _, err := time.Parse(lifecycleDateLayout, date); err != nil {
+		return fmt.Errorf("invalid date %q, expect YYYY-MM-DD", date)
+	}
+	return nil
+}
+
+// validTransitions check transitions, current Object transitions can be on date
+func validTransitions(transitions []lifecycleTransition, noncurrent bool) error {
+	classes := map[string]bool{}
+	for _, t := range transitions {
+		valid := false
+		for _, v := range s3.TransitionStorageClass_Values() {
+			valid = valid || t.StorageClass == v
+		}
+		if !valid {
+			return fmt.Errorf("invalid transition storage class %q(%s)", t.StorageClass, strings.Join(s3.TransitionStorageClass_Values(), ", "))
+		}
+		if classes[t.StorageClass] {
+			return fmt.Errorf("duplicate transition to %s", t.StorageClass)
+		}
+		classes[t.StorageClass] = true
+		switch {
+		case t.Date != "" && (noncurrent || t.Days != 0):
+			return fmt.Errorf("transition to %s: expect either days or date(current versions only)", t.StorageClass)
+		case t.Date != "":
+			if err := validLifecycleDate(t.Date); err != nil {
+				return fmt.Errorf("transition to %s: %w", t.StorageClass, err)
+			}
+		case t.Days < 0:
+			return fmt.Errorf("transition to %s: days must not be negative", t.StorageClass)
+		case t.Days < minIATransitionDays && (t.StorageClass == s3.TransitionStorageClassStandardIa || t.StorageClass == s3.TransitionStorageClassOnezoneIa):
+			return fmt.Errorf("transition to %s: days must be at least %d", t.StorageClass, minIATransitionDays)
+		}
+	}
+	return nil
+}
+
+// validLifecycleRule check a lifecycle rule
+func validLifecycleRule(r lifecycleRule) error {
+	if len(r.ID) > maxLifecycleRuleID {
+		return fmt.Errorf("ID longer than %d characters", maxLifecycleRuleID)
+	}
+	if r.Status != s3.ExpirationStatusEnabled && r.Status != s3.ExpirationStatusDisabled {
+		return fmt.Errorf("invalid status %q(%s, %s)", r.Status, s3.ExpirationStatusEnabled, s3.ExpirationStatusDisabled)
+	}
+	if r.ExpirationDays == 0 && r.ExpirationDate == "" && !r.ExpiredObjectDeleteMarker && r.NoncurrentExpirationDays == 0 &&
+		r.AbortIncompleteUploadDays == 0 && len(r.Transitions) == 0 && len(r.NoncurrentTransitions) == 0 {
+		return errors.New("no action(expiration, noncurrent expiration, abort incomplete upload or transition)")
+	}
+	if r.ExpirationDays < 0 || r.NoncurrentExpirationDays < 0 || r.AbortIncompleteUploadDays < 0 {
+		return errors.New("days must not be negative")
+	}
+	if r.ExpirationDays > 0 && r.ExpirationDate != "" {
+		return errors.New("expect either expiration days or expiration date")
+	}
+	if r.ExpirationDate != "" {
+		if err := validLifecycleDate(r.ExpirationDate); err != nil {
+			return fmt.Errorf("expiration: %w", err)
+		}
+	}
+	if r.ExpiredObjectDeleteMarker && (r.ExpirationDays > 0 || r.ExpirationDate != "") {
+		return errors.New("expired object delete marker can not be used with expiration days or date")
+	}
+	if len(r.Tags) > 0 && (r.ExpiredObjectDeleteMarker || r.AbortIncompleteUploadDays > 0) {
+		return errors.New("expired object delete marker and abort incomplete upload can not be used with tag filter")
+	}
+	if err := validTransitions(r.Transitions, false); err != nil {
+		return err
+	}
+	if err := validTransitions(r.NoncurrentTransitions, true); err != nil {
+		return err
+	}
+	for _, t := range r.Transitions {
+		if r.ExpirationDays > 0 && t.Days >= r.ExpirationDays {
+			return fmt.Errorf("transition to %s after %d days, not before expiration(%d days)", t.StorageClass, t.Days, r.ExpirationDays)
+		}
+	}
+	for _, t := range r.NoncurrentTransitions {
+		if r.NoncurrentExpirationDays > 0 && t.Days >= r.NoncurrentExpirationDays {
+			return fmt.Errorf("noncurrent transition to %s after %d days, not before noncurrent expiration(%d days)", t.StorageClass, t.Days, r.NoncurrentExpirationDays)
+		}
+	}
+	return nil
+}
+
+// validLifecycle check lifecycle rules locally before PutBucketLifecycleConfiguration
+func validLifecycle(rules []lifecycleRule) error {
+	if len(rules) == 0 {
+		return errors.New("no lifecycle rule")
+	}
+	if len(rules) > maxLifecycleRules {
+		return fmt.Errorf("too many lifecycle rules(> %d)", maxLifecycleRules)
+	}
+	ids := map[string]bool{}
+	for i, r := range rules {
+		if err := validLifecycleRule(r); err != nil {
+			return fmt.Errorf("invalid lifecycle rule %d(%s): %w", i+1, r.ID, err)
+		}
+		if r.ID != "" && ids[r.ID] {
+			return fmt.Errorf("invalid lifecycle rule %d: duplicate ID %s", i+1, r.ID)
+		}
+		ids[r.ID] = true
+	}
+	return nil
+}
+
+// lifecycleDate convert a valid date(YYYY-MM-DD) to midnight UTC
+func lifecycleDate(date string) *time.Time {
+	t, _ := time.Parse(lifecycleDateLayout, date)
+	return &t
+}
+
+// toS3 convert a readable rule to s3.LifecycleRule
+func (r lifecycleRule) toS3() *s3.LifecycleRule {
+	rule := &s3.LifecycleRule{
+		Status: aws.String(r.Status),
+		Filter: &s3.LifecycleRuleFilter{},
+	}
+	if r.ID != "" {
+		rule.ID = aws.String(r.ID)
+	}
+	switch tags := sortedTags(r.Tags); {
+	case len(tags) == 0:
+		rule.Filter.Prefix = aws.String(r.Prefix)
+	case len(tags) == 1 && r.Prefix == "":
+		rule.Filter.Tag = tags[0]
+	default:
+		rule.Filter.And = &s3.LifecycleRuleAndOperator{Tags: tags}
+		if r.Prefix != "" {
+			rule.Filter.And.Prefix = aws.String(r.Prefix)
+		}
+	}
+	if r.ExpirationDays > 0 || r.ExpirationDate != "" || r.ExpiredObjectDeleteMarker {
+		rule.Expiration = &s3.LifecycleExpiration{}
+		if r.ExpirationDays > 0 {
+			rule.Expiration.Days = aws.Int64(r.ExpirationDays)
+		}
+		if r.ExpirationDate != "" {
+			rule.Expiration.Date = lifecycleDate(r.ExpirationDate)
+		}
+		if r.ExpiredObjectDeleteMarker {
+			rule.Expiration.ExpiredObjectDeleteMarker = aws.Bool(true)
+		}
+	}
+	if r.NoncurrentExpirationDays > 0 {
+		rule.NoncurrentVersionExpiration = &s3.NoncurrentVersionExpiration{NoncurrentDays: aws.Int64(r.NoncurrentExpirationDays)}
+	}
+	if r.AbortIncompleteUploadDays > 0 {
+		rule.AbortIncompleteMultipartUpload = &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(r.AbortIncompleteUploadDays)}
+	}
+	for _, t := range r.Transitions {
+		transition := &s3.Transition{StorageClass: aws.String(t.StorageClass)}
+		if t.Date != "" {
+			transition.Date = lifecycleDate(t.Date)
+		} else {
+			transition.Days = aws.Int64(t.Days)
+		}
+		rule.Transitions = append(rule.Transitions, transition)
+	}
+	for _, t := range r.NoncurrentTransitions {
+		rule.NoncurrentVersionTransitions = append(rule.NoncurrentVersionTransitions, &s3.NoncurrentVersionTransition{
+			NoncurrentDays: aws.Int64(t.Days),
+			StorageClass:   aws.String(t.StorageClass),
+		})
+	}
+	return rule
+}
+
+// sortedTags convert tags map to s3.Tag sorted by key
+func sortedTags(m map[string]string) []*s3.Tag {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tags := make([]*s3.Tag, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, &s3.Tag{Key: aws.String(k), Value: aws.String(m[k])})
+	}
+	return tags
+}
+
+// newLifecycleRule convert a s3.LifecycleRule to readable rule
+func newLifecycleRule(rule *s3.LifecycleRule) lifecycleRule {
+	r := lifecycleRule{
+		ID:     aws.StringValue(rule.ID),
+		Status: aws.StringValue(rule.Status),
+		Prefix: aws.StringValue(rule.Prefix), // deprecated rule prefix
+	}
+	addTag := func(t *s3.Tag) {
+		if r.Tags == nil {
+			r.Tags = map[string]string{}
+		}
+		r.Tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	if f := rule.Filter; f != nil {
+		if f.Prefix != nil {
+			r.Prefix = aws.StringValue(f.Prefix)
+		}
+		if f.Tag != nil {
+			addTag(f.Tag)
+		}
+		if f.And != nil {
+			r.Prefix = aws.StringValue(f.And.Prefix)
+			for _, t := range f.And.Tags {
+				addTag(t)
+			}
+		}
+	}
+	if e := rule.Expiration; e != nil {
+		r.ExpirationDays = aws.Int64Value(e.Days)
+		if e.Date != nil {
+			r.ExpirationDate = e.Date.UTC().Format(lifecycleDateLayout)
+		}
+		r.ExpiredObjectDeleteMarker = aws.BoolValue(e.ExpiredObjectDeleteMarker)
+	}
+	if e := rule.NoncurrentVersionExpiration; e != nil {
+		r.NoncurrentExpirationDays = aws.Int64Value(e.NoncurrentDays)
+	}
+	if a := rule.AbortIncompleteMultipartUpload; a != nil {
+		r.AbortIncompleteUploadDays = aws.Int64Value(a.DaysAfterInitiation)
+	}
+	for _, t := range rule.Transitions {
+		transition := lifecycleTransition{Days: aws.Int64Value(t.Days), StorageClass: aws.StringValue(t.StorageClass)}
+		if t.Date != nil {
+			transition.Date = t.Date.UTC().Format(lifecycleDateLayout)
+		}
+		r.Transitions = append(r.Transitions, transition)
+	}
+	for _, t := range rule.NoncurrentVersionTransitions {
+		r.NoncurrentTransitions = append(r.NoncurrentTransitions, lifecycleTransition{
+			Days:         aws.Int64Value(t.NoncurrentDays),
+			StorageClass: aws.StringValue(t.StorageClass),
+		})
+	}
+	return r
+}
+
+// filterString format the filter of a readable rule
+func (r lifecycleRule) filterString() string {
+	filters := []string{}
+	if r.Prefix != "" {
+		filters = append(filters, "prefix="+r.Prefix)
+	}
+	for _, t := range sortedTags(r.Tags) {
+		filters = append(filters, fmt.Sprintf("tag:%s=%s", aws.StringValue(t.Key), aws.StringValue(t.Value)))
+	}
+	if len(filters) == 0 {
+		return "*"
+	}
+	return strings.Join(filters, ",")
+}
+
+// actionStrings format the actions of a readable rule
+func (r lifecycleRule) actionStrings() []string {
+	actions := []string{}
+	transition := func(prefix string, t lifecycleTransition) string {
+		if t.Date != "" {
+			return fmt.Sprintf("%s to %s on %s", prefix, t.StorageClass, t.Date)
+		}
+		return fmt.Sprintf("%s to %s after %dd", prefix, t.StorageClass, t.Days)
+	}
+	for _, t := range r.Transitions {
+		actions = append(actions, transition("transition", t))
+	}
+	if r.ExpirationDays > 0 {
+		actions = append(actions, fmt.Sprintf("expire after %dd", r.ExpirationDays))
+	}
+	if r.ExpirationDate != "" {
+		actions = append(actions, "expire on "+r.ExpirationDate)
+	}
+	if r.ExpiredObjectDeleteMarker {
+		actions = append(actions, "remove expired delete markers")
+	}
+	for _, t := range r.NoncurrentTransitions {
+		actions = append(actions, transition("noncurrent transition", t))
+	}
+	if r.NoncurrentExpirationDays > 0 {
+		actions = append(actions, fmt.Sprintf("expire noncurrent after %dd", r.NoncurrentExpirationDays))
+	}
+	if r.AbortIncompleteUploadDays > 0 {
+		actions = append(actions, fmt.Sprintf("abort incomplete MPU after %dd", r.AbortIncompleteUploadDays))
+	}
+	return actions
+}
+
+// getLifecycle get the lifecycle rules of a Bucket, empty if the Bucket has no lifecycle configuration
+func (sc *S3Cli) getLifecycle(bucket string) ([]lifecycleRule, error) {
+	resp, err := sc.Client.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errNoSuchLifecycle {
+			return []lifecycleRule{}, nil
+		}
+		return nil, fmt.Errorf("get bucket lifecycle failed: %w", err)
+	}
+	rules := make([]lifecycleRule, 0, len(resp.Rules))
+	for _, rule := range resp.Rules {
+		rules = append(rules, newLifecycleRule(rule))
+	}
+	return rules, nil
+}
+
+// bucketLifecycleGet print the lifecycle rules of a Bucket
+func (sc *S3Cli) bucketLifecycleGet(bucket string) error {
+	if sc.presign {
+		req, _ := sc.Client.GetBucketLifecycleConfigurationRequest(&s3.GetBucketLifecycleConfigurationInput{
+			Bucket: aws.String(bucket),
+		})
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+	rules, err := sc.getLifecycle(bucket)
+	if err != nil {
+		return err
+	}
+	return sc.printOutput(lifecycleOutput{Bucket: bucket, Rules: rules}, func() {
+		if len(rules) == 0 {
+			fmt.Printf("no lifecycle rule of %s\n", bucket)
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tFILTER\tACTIONS")
+		for _, r := range rules {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.ID, r.Status, r.filterString(), strings.Join(r.actionStrings(), "; "))
+		}
+		w.Flush()
+	})
+}
+
+// bucketLifecycleSet validate and replace the lifecycle rules of a Bucket
+func (sc *S3Cli) bucketLifecycleSet(bucket string, rules []lifecycleRule) error {
+	if err := validLifecycle(rules); err != nil {
+		return err
+	}
+	cfg := &s3.BucketLifecycleConfiguration{}
+	for _, r := range rules {
+		cfg.Rules = append(cfg.Rules, r.toS3())
+	}
+	req, resp := sc.Client.PutBucketLifecycleConfigurationRequest(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucket),
+		LifecycleConfiguration: cfg,
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("put bucket lifecycle failed: %w", err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// bucketLifecycleAddRule add a rule to the lifecycle rules of a Bucket
+func (sc *S3Cli) bucketLifecycleAddRule(bucket string, rule lifecycleRule) error {
+	rules, err := sc.getLifecycle(bucket)
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if rule.ID != "" && r.ID == rule.ID {
+			return fmt.Errorf("lifecycle rule %s already exists", rule.ID)
+		}
+	}
+	return sc.bucketLifecycleSet(bucket, append(rules, rule))
+}
+
+// bucketLifecycleDelete delete the lifecycle configuration of a Bucket, or only the rule of id if not empty
+func (sc *S3Cli) bucketLifecycleDelete(bucket, id string) error {
+	if id != "" {
+		rules, err := sc.getLifecycle(bucket)
+		if err != nil {
+			return err
+		}
+		kept := []lifecycleRule{}
+		for _, r := range rules {
+			if r.ID != id {
+				kept = append(kept, r)
+			}
+		}
+		if len(kept) == len(rules) {
+			return fmt.Errorf("lifecycle rule %s not found", id)
+		}
+		if len(kept) > 0 {
+			return sc.bucketLifecycleSet(bucket, kept)
+		}
+	}
+
+	req, resp := sc.Client.DeleteBucketLifecycleRequest(&s3.DeleteBucketLifecycleInput{
+		Bucket: aws.String(bucket),
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("delete bucket lifecycle failed: %w", err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
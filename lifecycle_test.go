@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func Test_parseTransition(t *testing.T) {
+	cases := map[string]lifecycleTransition{
+		"30:STANDARD_IA":     {Days: 30, StorageClass: "STANDARD_IA"},
+		"0:glacier":          {Days: 0, StorageClass: "GLACIER"},
+		"2030-01-01:GLACIER": {Date: "2030-01-01", StorageClass: "GLACIER"},
+	}
+	for k, v := range cases {
+		if got, err := parseTransition(k); err != nil || got != v {
+			t.Errorf("parseTransition %s expect: %+v, got: %+v, %v", k, v, got, err)
+		}
+	}
+	for _, v := range []string{"30", ":GLACIER", "30:"} {
+		if _, err := parseTransition(v); err == nil {
+			t.Errorf("expect error for transition %s", v)
+		}
+	}
+}
+
+func Test_validLifecycle(t *testing.T) {
+	valid := lifecycleRule{ID: "a", Status: "Enabled", ExpirationDays: 90, Transitions: []lifecycleTransition{{Days: 30, StorageClass: "STANDARD_IA"}}}
+	if err := validLifecycle([]lifecycleRule{valid}); err != nil {
+		t.Errorf("validLifecycle failed: %s", err)
+	}
+
+	invalid := map[string]func(r *lifecycleRule){
+		"status":           func(r *lifecycleRule) { r.Status = "enabled" },
+		"no action":        func(r *lifecycleRule) { r.ExpirationDays = 0; r.Transitions = nil },
+		"days and date":    func(r *lifecycleRule) { r.ExpirationDate = "2030-01-01" },
+		"bad date":         func(r *lifecycleRule) { r.ExpirationDays = 0; r.ExpirationDate = "2030/01/01" },
+		"delete marker":    func(r *lifecycleRule) { r.ExpiredObjectDeleteMarker = true },
+		"tag abort":        func(r *lifecycleRule) { r.Tags = map[string]string{"k": "v"}; r.AbortIncompleteUploadDays = 1 },
+		"storage class":    func(r *lifecycleRule) { r.Transitions[0].StorageClass = "STANDARD" },
+		"IA days":          func(r *lifecycleRule) { r.Transitions[0].Days = 7 },
+		"after expiration": func(r *lifecycleRule) { r.ExpirationDays = 30 },
+		"duplicate class":  func(r *lifecycleRule) { r.Transitions = append(r.Transitions, r.Transitions[0]) },
+		"noncurrent date": func(r *lifecycleRule) {
+			r.NoncurrentTransitions = []lifecycleTransition{{Date: "2030-01-01", StorageClass: "GLACIER"}}
+		},
+		"negative days": func(r *lifecycleRule) { r.NoncurrentExpirationDays = -1 },
+		"noncurrent ordered": func(r *lifecycleRule) {
+			r.NoncurrentExpirationDays = 1
+			r.NoncurrentTransitions = []lifecycleTransition{{Days: 1, StorageClass: "GLACIER"}}
+		},
+	}
+	for name, fn := range invalid {
+		r := valid
+		r.Transitions = append([]lifecycleTransition{}, valid.Transitions...)
+		fn(&r)
+		if err := validLifecycle([]lifecycleRule{r}); err == nil {
+			t.Errorf("expect error for %s: %+v", name, r)
+		}
+	}
+	if err := validLifecycle([]lifecycleRule{valid, valid}); err == nil {
+		t.Errorf("expect error for duplicate ID")
+	}
+	if err := validLifecycle(nil); err == nil {
+		t.Errorf("expect error for no rule")
+	}
+	if err := s3cliTest.bucketLifecycleSet(testBucketName, nil); err == nil {
+		t.Errorf("expect bucketLifecycleSet error for no rule")
+	}
+}
+
+func Test_lifecycleRuleConvert(t *testing.T) {
+	rules := []lifecycleRule{
+		{ID: "prefix", Status: "Enabled", Prefix: "logs/", ExpirationDays: 90, NoncurrentExpirationDays: 7, AbortIncompleteUploadDays: 3,
+			Transitions: []lifecycleTransition{{Days: 30, StorageClass: "STANDARD_IA"}}},
+		{ID: "tag", Status: "Disabled", Tags: map[string]string{"class": "archive"},
+			Transitions: []lifecycleTransition{{Date: "2030-01-01", StorageClass: "GLACIER"}}},
+		{ID: "and", Status: "Enabled", Prefix: "tmp/", Tags: map[string]string{"a": "1", "b": "2"}, ExpirationDate: "2030-01-01",
+			NoncurrentTransitions: []lifecycleTransition{{Days: 1, StorageClass: "GLACIER"}}},
+		{Status: "Enabled", ExpiredObjectDeleteMarker: true},
+	}
+	for _, r := range rules {
+		rule := r.toS3()
+		if got := newLifecycleRule(rule); !reflect.DeepEqual(got, r) {
+			t.Errorf("expect: %+v, got: %+v", r, got)
+		}
+	}
+	if rule := rules[1].toS3(); rule.Filter.Tag == nil || aws.StringValue(rule.Filter.Tag.Key) != "class" {
+		t.Errorf("expect single tag filter: %s", rule.Filter)
+	}
+
+	if got := rules[2].filterString(); got != "prefix=tmp/,tag:a=1,tag:b=2" {
+		t.Errorf("unexpected filter: %s", got)
+	}
+	if got := rules[3].filterString(); got != "*" {
+		t.Errorf("unexpected filter: %s", got)
+	}
+	actions := strings.Join(rules[0].actionStrings(), "; ")
+	if expect := "transition to STANDARD_IA after 30d; expire after 90d; expire noncurrent after 7d; abort incomplete MPU after 3d"; actions != expect {
+		t.Errorf("expect actions: %s, got: %s", expect, actions)
+	}
+}
@@ -10,12 +10,15 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3control"
@@ -50,7 +53,7 @@ type Credentials struct {
 	Endpoint    string `json:"endpoint"`
 	AccessKeyId string `json:"accessKeyId"`
 	SecretKeyId string `json:"secretKeyId"`
-	Bucket      string `json:"secretKeyId"`
+	Bucket      string `json:"bucket"`
 }
 
 func setCredentials(sc *S3Cli) error {
@@ -89,6 +92,106 @@ func splitBucketObject(bucketObject string) (bucket, object string) {
 	return bucketObject, ""
 }
 
+// parseSize parse a size like 1024, 64K, 16M, 5G(5GB, 5GiB) or 1T(1024 based)
+func parseSize(size string) (int64, error) {
+	s := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B")
+	shift := uint(0)
+	if units := "KMGT"; len(s) > 1 {
+		unit := strings.TrimSuffix(s, "I")
+		if i := strings.IndexByte(units, unit[len(unit)-1]); i >= 0 {
+			shift = uint(i+1) * 10
+			s = unit[:len(unit)-1]
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %s", size)
+	}
+	return n << shift, nil
+}
+
+// parseAge parse an age like 30d, 2w or any time.Duration(12h, 90m)
+func parseAge(age string) (time.Duration, error) {
+	s := strings.TrimSpace(age)
+	day := 24 * time.Hour
+	for suffix, unit := range map[string]time.Duration{"d": day, "w": 7 * day} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age: %s", age)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age: %s", age)
+	}
+	return d, nil
+}
+
+// parseTags parse tags like key1=value1 key2=value2
+func parseTags(tags []string) ([]*s3.Tag, error) {
+	result := make([]*s3.Tag, 0, len(tags))
+	for _, v := range tags {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid tag: %s(key=value)", v)
+		}
+		result = append(result, &s3.Tag{Key: aws.String(kv[0]), Value: aws.String(kv[1])})
+	}
+	return result, nil
+}
+
+// setSSEFlags set SSE options from cmd flags
+func setSSEFlags(sc *S3Cli, cmd *cobra.Command) (err error) {
+	if f := cmd.Flag("sse-kms-key-id"); f != nil {
+		sc.sse.kmsKeyID = f.Value.String()
+	}
+	if f := cmd.Flag("sse"); f != nil {
+		if sc.sse.sse, err = validSSE(f.Value.String(), sc.sse.kmsKeyID); err != nil {
+			return err
+		}
+	}
+	if f := cmd.Flag("sse-c-key"); f != nil && f.Value.String() != "" {
+		if sc.sse.customerKey, err = loadSSECKey(f.Value.String()); err != nil {
+			return err
+		}
+	}
+	if f := cmd.Flag("sse-c-source-key"); f != nil && f.Value.String() != "" {
+		if sc.sse.sourceKey, err = loadSSECKey(f.Value.String()); err != nil {
+			return err
+		}
+	}
+	if sc.sse.sse != "" && sc.sse.customerKey != "" {
+		return fmt.Errorf("--sse can not be used with --sse-c-key")
+	}
+	return nil
+}
+
+// setMpuFlags set MPU part size, threshold and concurrency from cmd flags
+func setMpuFlags(sc *S3Cli, cmd *cobra.Command) (err error) {
+	if f := cmd.Flag("part-size"); f != nil {
+		if sc.partSize, err = parseSize(f.Value.String()); err != nil {
+			return err
+		}
+	}
+	if f := cmd.Flag("threshold"); f != nil {
+		if sc.threshold, err = parseSize(f.Value.String()); err != nil {
+			return err
+		}
+	}
+	if f := cmd.Flag("resume"); f != nil {
+		sc.resume = f.Changed
+	}
+	if f := cmd.Flag("concurrency"); f != nil {
+		if sc.concurrency, err = cmd.Flags().GetInt("concurrency"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func newS3Client(sc *S3Cli) (*s3.S3, error) {
 	setCredentials(sc)
 	os.Setenv("AWS_ACCESS_KEY_ID", sc.ak)
@@ -111,8 +214,33 @@ func newS3Client(sc *S3Cli) (*s3.S3, error) {
 	return svc, nil
 }
 
+// newEndpointClient create a S3 client of another endpoint, with the access/secret key of sc if ak/sk is empty
+func newEndpointClient(sc *S3Cli, endpoint, ak, sk string) (*s3.S3, error) {
+	if ak == "" {
+		ak = sc.ak
+	}
+	if sk == "" {
+		sk = sc.sk
+	}
+	sess, err := session.NewSession(&aws.Config{
+		Credentials:      credentials.NewStaticCredentials(ak, sk, ""),
+		Endpoint:         aws.String(endpoint),
+		Region:           aws.String(sc.region),
+		MaxRetries:       aws.Int(0),
+		S3ForcePathStyle: aws.Bool(!virtualhost),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sc.debug {
+		sess.Config.LogLevel = aws.LogLevel(aws.LogDebug)
+	}
+	return s3.New(sess), nil
+}
+
 func main() {
 	sc := S3Cli{}
+	limitRate := ""
 	var rootCmd = &cobra.Command{
 		Use:   "s3cli",
 		Short: "s3cli client tool",
@@ -128,6 +256,16 @@ Credential EnvVar:
 		Version: version,
 		Hidden:  true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := validOutput(sc.output); err != nil {
+				return err
+			}
+			if limitRate != "" {
+				rate, err := parseSize(limitRate)
+				if err != nil || rate <= 0 {
+					return fmt.Errorf("invalid --limit-rate: %s", limitRate)
+				}
+				sc.limiter = newRateLimiter(rate)
+			}
 			client, err := newS3Client(&sc)
 			if err != nil {
 				return err
@@ -138,8 +276,12 @@ Credential EnvVar:
 	}
 	rootCmd.PersistentFlags().BoolVarP(&sc.debug, "debug", "", false, "print debug log")
 	rootCmd.PersistentFlags().BoolVarP(&sc.verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&sc.quiet, "quiet", "q", false, "do not show transfer progress on stderr")
+	rootCmd.PersistentFlags().StringVarP(&sc.output, "output", "o", outputText, "output format(text, json, yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&sc.presign, "presign", "", false, "presign URL and exit")
 	rootCmd.PersistentFlags().DurationVarP(&sc.presignExp, "expire", "", 24*time.Hour, "presign URL expiration")
+	rootCmd.PersistentFlags().StringVarP(&limitRate, "limit-rate", "", "", "limit the total transfer rate in bytes per second(like 20M)")
+	rootCmd.PersistentFlags().BoolVarP(&sc.noVerify, "no-verify", "", false, "skip Content-MD5 on upload and checksum verification on download")
 	rootCmd.PersistentFlags().StringVarP(&sc.endpoint, "endpoint", "e", "", "S3 endpoint(http://host:port)")
 	rootCmd.PersistentFlags().StringVarP(&sc.profile, "profile", "p", "", "profile in credentials file")
 	rootCmd.PersistentFlags().StringVarP(&sc.region, "region", "R", s3.BucketLocationConstraintCnNorth1, "S3 region")
@@ -303,6 +445,72 @@ Credential EnvVar:
 	}
 	bucketCmd.AddCommand(bucketPolicyCmd)
 
+	// bucket sub-command website
+	bucketWebsiteCmd := &cobra.Command{
+		Use:     "website <bucket>",
+		Aliases: []string{"web"},
+		Short:   "set/get Bucket website",
+		Long: `set/get Bucket static website hosting usage:
+* set Bucket website with index and error document
+	s3cli b website bucket-name --index index.html --error 404.html
+* set Bucket website and add the public-read statement required to serve it to the Bucket policy
+	s3cli b website bucket-name --index index.html --public
+* set Bucket website with routing rules from a JSON/YAML file
+	s3cli b website bucket-name --index index.html --routing-rules rules.yaml
+* get Bucket website
+	s3cli b website get bucket-name
+* delete Bucket website
+	s3cli b website delete bucket-name
+
+* routing rules file(YAML, or JSON with the same fields, like -o json output of website get):
+	rules:
+	- condition: {keyPrefixEquals: docs/old/}
+	  redirect: {replaceKeyPrefixWith: docs/new/}
+	- condition: {httpErrorCodeReturnedEquals: "404"}
+	  redirect: {hostName: example.com, protocol: https, httpRedirectCode: "302"}
+* --index is required, the website configuration is replaced by set
+* rules are validated locally before they are sent
+* --public keeps the other statements of the Bucket policy, and skips if PublicReadGetObject is already set`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var rules []routingRule
+			if filename := cmd.Flag("routing-rules").Value.String(); filename != "" {
+				var err error
+				if rules, err = loadRoutingRules(filename); err != nil {
+					return err
+				}
+			}
+			return sc.bucketWebsiteSet(args[0], cmd.Flag("index").Value.String(), cmd.Flag("error").Value.String(),
+				rules, cmd.Flag("public").Changed)
+		},
+	}
+	bucketWebsiteCmd.Flags().StringP("index", "", "", "index document suffix(required)")
+	bucketWebsiteCmd.Flags().StringP("error", "", "", "error document key")
+	bucketWebsiteCmd.Flags().StringP("routing-rules", "", "", "routing rules JSON/YAML file")
+	bucketWebsiteCmd.Flags().BoolP("public", "", false, "also add the public-read statement to the Bucket policy")
+	bucketCmd.AddCommand(bucketWebsiteCmd)
+
+	bucketWebsiteGetCmd := &cobra.Command{
+		Use:   "get <bucket>",
+		Short: "get Bucket website",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sc.bucketWebsiteGet(args[0])
+		},
+	}
+	bucketWebsiteCmd.AddCommand(bucketWebsiteGetCmd)
+
+	bucketWebsiteDeleteCmd := &cobra.Command{
+		Use:     "delete <bucket>",
+		Aliases: []string{"rm"},
+		Short:   "delete Bucket website",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sc.bucketWebsiteDelete(args[0])
+		},
+	}
+	bucketWebsiteCmd.AddCommand(bucketWebsiteDeleteCmd)
+
 	// bucket sub-command version
 	bucketVersionCmd := &cobra.Command{
 		Use:     "version <bucket> [status]",
@@ -335,6 +543,276 @@ Credential EnvVar:
 	}
 	bucketCmd.AddCommand(bucketVersionCmd)
 
+	// bucket sub-command lifecycle
+	bucketLifecycleCmd := &cobra.Command{
+		Use:     "lifecycle <bucket>",
+		Aliases: []string{"lc"},
+		Short:   "get/set Bucket lifecycle",
+		Long: `get/set Bucket lifecycle rules usage:
+* get Bucket lifecycle rules
+	s3cli b lifecycle bucket-name
+* set(replace) Bucket lifecycle rules from a JSON/YAML file
+	s3cli b lifecycle set bucket-name lifecycle.yaml
+* add a rule: expire logs/ after 90 days and transition them to STANDARD_IA after 30 days
+	s3cli b lifecycle add-rule bucket-name --id logs --prefix logs/ --expire-days 90 --transition 30:STANDARD_IA
+* delete all lifecycle rules, or only a rule
+	s3cli b lifecycle delete bucket-name
+	s3cli b lifecycle delete bucket-name --id logs
+
+* lifecycle file(YAML, or JSON with the same fields, like -o json output of lifecycle get):
+	rules:
+	- id: logs
+	  status: Enabled
+	  prefix: logs/
+	  tags: {env: dev}
+	  expirationDays: 90
+	  noncurrentExpirationDays: 7
+	  abortIncompleteUploadDays: 3
+	  transitions:
+	  - {days: 30, storageClass: STANDARD_IA}
+	  noncurrentTransitions:
+	  - {days: 1, storageClass: GLACIER}
+* --index is required, the website configuration is replaced by set
+* rules are validated locally before they are sent
+* --public keeps the other statements of the Bucket policy, and skips if PublicReadGetObject is already set`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sc.bucketLifecycleGet(args[0])
+		},
+	}
+	bucketCmd.AddCommand(bucketLifecycleCmd)
+
+	bucketLifecycleSetCmd := &cobra.Command{
+		Use:   "set <bucket> <file>",
+		Short: "set Bucket lifecycle rules from a JSON/YAML file",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rules, err := loadLifecycle(args[1])
+			if err != nil {
+				return err
+			}
+			return sc.bucketLifecycleSet(args[0], rules)
+		},
+	}
+	bucketLifecycleCmd.AddCommand(bucketLifecycleSetCmd)
+
+	bucketLifecycleAddRuleCmd := &cobra.Command{
+		Use:   "add-rule <bucket>",
+		Short: "add a Bucket lifecycle rule",
+		Long: `add a Bucket lifecycle rule usage:
+* expire Objects with prefix(tmp/) after 7 days
+	s3cli b lifecycle add-rule bucket-name --id tmp --prefix tmp/ --expire-days 7
+* expire noncurrent versions after 30 days and abort incomplete MPU after 3 days
+	s3cli b lifecycle add-rule bucket-name --id cleanup --noncurrent-expire-days 30 --abort-mpu-days 3
+* transition Objects tagged class=archive to GLACIER after 1 day
+	s3cli b lifecycle add-rule bucket-name --id archive --tag class=archive --transition 1:GLACIER`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rule := lifecycleRule{
+				ID:     cmd.Flag("id").Value.String(),
+				Status: s3.ExpirationStatusEnabled,
+				Prefix: cmd.Flag("prefix").Value.String(),
+			}
+			if cmd.Flag("disabled").Changed {
+				rule.Status = s3.ExpirationStatusDisabled
+			}
+			tags, err := cmd.Flags().GetStringArray("tag")
+			if err != nil {
+				return err
+			}
+			tagSet, err := parseTags(tags)
+			if err != nil {
+				return err
+			}
+			for _, t := range tagSet {
+				if rule.Tags == nil {
+					rule.Tags = map[string]string{}
+				}
+				rule.Tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+			}
+			if rule.ExpirationDays, err = cmd.Flags().GetInt64("expire-days"); err != nil {
+				return err
+			}
+			rule.ExpirationDate = cmd.Flag("expire-date").Value.String()
+			rule.ExpiredObjectDeleteMarker = cmd.Flag("expired-delete-marker").Changed
+			if rule.NoncurrentExpirationDays, err = cmd.Flags().GetInt64("noncurrent-expire-days"); err != nil {
+				return err
+			}
+			if rule.AbortIncompleteUploadDays, err = cmd.Flags().GetInt64("abort-mpu-days"); err != nil {
+				return err
+			}
+			for flag, transitions := range map[string]*[]lifecycleTransition{
+				"transition":            &rule.Transitions,
+				"noncurrent-transition": &rule.NoncurrentTransitions,
+			} {
+				values, err := cmd.Flags().GetStringArray(flag)
+				if err != nil {
+					return err
+				}
+				for _, v := range values {
+					t, err := parseTransition(v)
+					if err != nil {
+						return err
+					}
+					*transitions = append(*transitions, t)
+				}
+			}
+			return sc.bucketLifecycleAddRule(args[0], rule)
+		},
+	}
+	bucketLifecycleAddRuleCmd.Flags().StringP("id", "", "", "rule ID")
+	bucketLifecycleAddRuleCmd.Flags().StringP("prefix", "", "", "apply the rule to Objects with prefix")
+	bucketLifecycleAddRuleCmd.Flags().StringArrayP("tag", "", nil, "apply the rule to Objects with tag(key=value)")
+	bucketLifecycleAddRuleCmd.Flags().BoolP("disabled", "", false, "add the rule disabled")
+	bucketLifecycleAddRuleCmd.Flags().Int64P("expire-days", "", 0, "expire Objects after days")
+	bucketLifecycleAddRuleCmd.Flags().StringP("expire-date", "", "", "expire Objects on date(YYYY-MM-DD)")
+	bucketLifecycleAddRuleCmd.Flags().BoolP("expired-delete-marker", "", false, "remove expired Object delete markers")
+	bucketLifecycleAddRuleCmd.Flags().Int64P("noncurrent-expire-days", "", 0, "expire noncurrent versions after days")
+	bucketLifecycleAddRuleCmd.Flags().Int64P("abort-mpu-days", "", 0, "abort incomplete MPU after days")
+	bucketLifecycleAddRuleCmd.Flags().StringArrayP("transition", "", nil, "transition Objects(<days|date>:<storage-class>, like 30:STANDARD_IA)")
+	bucketLifecycleAddRuleCmd.Flags().StringArrayP("noncurrent-transition", "", nil, "transition noncurrent versions(<days>:<storage-class>)")
+	bucketLifecycleCmd.AddCommand(bucketLifecycleAddRuleCmd)
+
+	bucketLifecycleDeleteCmd := &cobra.Command{
+		Use:     "delete <bucket>",
+		Aliases: []string{"rm"},
+		Short:   "delete Bucket lifecycle rules",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sc.bucketLifecycleDelete(args[0], cmd.Flag("id").Value.String())
+		},
+	}
+	bucketLifecycleDeleteCmd.Flags().StringP("id", "", "", "only delete the rule of ID")
+	bucketLifecycleCmd.AddCommand(bucketLifecycleDeleteCmd)
+
+	// bucket sub-command encryption
+	bucketEncryptionCmd := &cobra.Command{
+		Use:     "encryption <bucket>",
+		Aliases: []string{"enc"},
+		Short:   "get/set Bucket default encryption",
+		Long: `get/set Bucket default encryption usage:
+* get Bucket default encryption
+	s3cli b encryption bucket-name
+* set Bucket default encryption to SSE-S3
+	s3cli b encryption set bucket-name --sse AES256
+* set Bucket default encryption to SSE-KMS with key ID and S3 Bucket Key
+	s3cli b encryption set bucket-name --sse aws:kms --kms-key-id my-key-id --bucket-key
+* delete Bucket default encryption
+	s3cli b encryption delete bucket-name`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sc.bucketEncryptionGet(args[0])
+		},
+	}
+	bucketCmd.AddCommand(bucketEncryptionCmd)
+
+	bucketEncryptionSetCmd := &cobra.Command{
+		Use:   "set <bucket>",
+		Short: "set Bucket default encryption",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sc.bucketEncryptionSet(args[0], cmd.Flag("sse").Value.String(),
+				cmd.Flag("kms-key-id").Value.String(), cmd.Flag("bucket-key").Changed)
+		},
+	}
+	bucketEncryptionSetCmd.Flags().StringP("sse", "", "", "server-side encryption(AES256, aws:kms)")
+	bucketEncryptionSetCmd.Flags().StringP("kms-key-id", "", "", "SSE-KMS key ID(implies --sse aws:kms)")
+	bucketEncryptionSetCmd.Flags().BoolP("bucket-key", "", false, "enable S3 Bucket Key of SSE-KMS")
+	bucketEncryptionCmd.AddCommand(bucketEncryptionSetCmd)
+
+	bucketEncryptionDeleteCmd := &cobra.Command{
+		Use:     "delete <bucket>",
+		Aliases: []string{"rm"},
+		Short:   "delete Bucket default encryption",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sc.bucketEncryptionDelete(args[0])
+		},
+	}
+	bucketEncryptionCmd.AddCommand(bucketEncryptionDeleteCmd)
+
+	// bucket sub-command cors
+	bucketCORSCmd := &cobra.Command{
+		Use:   "cors <bucket>",
+		Short: "get/set Bucket CORS",
+		Long: `get/set Bucket CORS rules usage:
+* get Bucket CORS rules
+	s3cli b cors bucket-name
+* set(replace) Bucket CORS rules from a JSON/YAML file
+	s3cli b cors set bucket-name cors.yaml
+* delete Bucket CORS rules
+	s3cli b cors delete bucket-name
+* test a preflight request against the Bucket CORS rules(or a local file)
+	s3cli b cors test bucket-name --origin https://example.com --method PUT --header Content-Type
+	s3cli b cors test bucket-name --origin https://example.com --method GET --file cors.yaml
+
+* CORS file(YAML, or JSON with the same fields, like -o json output of cors get):
+	rules:
+	- id: web
+	  allowedOrigins: [https://example.com, https://*.example.com]
+	  allowedMethods: [GET, PUT]
+	  allowedHeaders: ["*"]
+	  exposeHeaders: [ETag]
+	  maxAgeSeconds: 3600
+* --index is required, the website configuration is replaced by set
+* rules are validated locally before they are sent
+* --public keeps the other statements of the Bucket policy, and skips if PublicReadGetObject is already set`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sc.bucketCORSGet(args[0])
+		},
+	}
+	bucketCmd.AddCommand(bucketCORSCmd)
+
+	bucketCORSSetCmd := &cobra.Command{
+		Use:   "set <bucket> <file>",
+		Short: "set Bucket CORS rules from a JSON/YAML file",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rules, err := loadCORS(args[1])
+			if err != nil {
+				return err
+			}
+			return sc.bucketCORSSet(args[0], rules)
+		},
+	}
+	bucketCORSCmd.AddCommand(bucketCORSSetCmd)
+
+	bucketCORSDeleteCmd := &cobra.Command{
+		Use:     "delete <bucket>",
+		Aliases: []string{"rm"},
+		Short:   "delete Bucket CORS rules",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sc.bucketCORSDelete(args[0])
+		},
+	}
+	bucketCORSCmd.AddCommand(bucketCORSDeleteCmd)
+
+	bucketCORSTestCmd := &cobra.Command{
+		Use:   "test <bucket>",
+		Short: "test a preflight request against Bucket CORS rules",
+		Long: `evaluate a simulated preflight(OPTIONS) request locally,
+print which rule matched and the response headers, or why no rule matched(exit with error)`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flag("origin").Value.String() == "" {
+				return fmt.Errorf("--origin is required")
+			}
+			headers, err := cmd.Flags().GetStringArray("header")
+			if err != nil {
+				return err
+			}
+			return sc.bucketCORSTest(args[0], cmd.Flag("file").Value.String(),
+				cmd.Flag("origin").Value.String(), cmd.Flag("method").Value.String(), headers)
+		},
+	}
+	bucketCORSTestCmd.Flags().StringP("origin", "", "", "request Origin")
+	bucketCORSTestCmd.Flags().StringP("method", "", "GET", "Access-Control-Request-Method")
+	bucketCORSTestCmd.Flags().StringArrayP("header", "", nil, "Access-Control-Request-Headers(repeatable)")
+	bucketCORSTestCmd.Flags().StringP("file", "", "", "test rules of a local JSON/YAML file instead of the Bucket")
+	bucketCORSCmd.AddCommand(bucketCORSTestCmd)
+
 	// bucket sub-command delete
 	bucketDeleteCmd := &cobra.Command{
 		Use:     "delete <bucket>",
@@ -352,7 +830,7 @@ Credential EnvVar:
 
 	// object put(upload)
 	putObjectCmd := &cobra.Command{
-		Use:     "put <bucket[/key]> [<local-file> ...]",
+		Use:     "put <bucket[/key]> [<local-file>|- ...]",
 		Aliases: []string{"up", "upload"},
 		Short:   "put Object(s)",
 		Long: `put(upload) Object(s) usage:
@@ -366,44 +844,157 @@ Credential EnvVar:
 * put(upload) files to Bucket with specified common prefix(dir/)
 	s3cli put bucket/dir/ file1 file2 file3
 	s3cli up bucket/dir2/ *.txt
+* put(upload) a directory tree to Bucket with specified common prefix(dir/)
+	s3cli put -r bucket/dir/ /path/to/dir
+* put(upload) a directory tree with 8 parallel uploads
+	s3cli put -r -j 8 bucket/dir/ /path/to/dir
+* put(upload) a large file with MPU, 64M part size and 8 parallel parts
+	s3cli put --part-size 64M --concurrency 8 bucket/key /path/to/large-file
+* put(upload) a large file with MPU and resume it after failure
+	s3cli put --resume bucket/key /path/to/large-file
+* put(upload) from stdin
+	tar c dir | s3cli put bucket/backup.tar -
+* put(upload) a file with an additional SHA256 checksum(x-amz-checksum-sha256), MPU uploads are refused
+	s3cli put --checksum-algorithm SHA256 bucket/key /path/to/file
+* put(upload) a directory tree with tags
+	s3cli put -r --tag team=data --tag env=dev bucket/dir/ /path/to/dir
+* put(upload) a file encrypted with SSE-KMS(or SSE-S3 with --sse AES256)
+	s3cli put --sse aws:kms --sse-kms-key-id my-key-id bucket/key /path/to/file
+* put(upload) a file encrypted with SSE-C(a 32 bytes key file, or its base64 encoding)
+	head -c 32 /dev/urandom > key.bin
+	s3cli put --sse-c-key key.bin bucket/key /path/to/file
 * presign(V4) a PUT Object URL
-	s3cli up bucket/key --presign`,
+	s3cli up bucket/key --presign
+
+* Objects and MPU parts are uploaded with Content-MD5, skip it with --no-verify
+* SSE-C keys are only sent to https endpoints
+* the part size of MPU Objects is stored in metadata(x-amz-meta-s3cli-part-size) to verify their ETag on download
+* files larger than --threshold are uploaded with MPU(Multi-Part-Upload)
+* --resume keeps MPU progress in <local-file>.s3cli-mpu until the upload completes
+* stdin(-) is uploaded with MPU if it is longer than --part-size, at most --concurrency parts
+  are buffered in memory, and at most 10000 parts(160G with 16M --part-size) can be uploaded`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			var fd *os.File
+			if err = setMpuFlags(&sc, cmd); err != nil {
+				return err
+			}
+			sc.checksum = strings.ToUpper(cmd.Flag("checksum-algorithm").Value.String())
+			if err = validChecksum(sc.checksum); err != nil {
+				return err
+			}
+			tags, err := cmd.Flags().GetStringArray("tag")
+			if err != nil {
+				return err
+			}
+			tagSet, err := parseTags(tags)
+			if err != nil {
+				return err
+			}
+			if err = validTags(tagSet, maxObjectTags); err != nil {
+				return err
+			}
+			sc.tagging = encodeTags(tagSet)
+			if err = setSSEFlags(&sc, cmd); err != nil {
+				return err
+			}
 			bucket, key := splitBucketObject(args[0])
+			if cmd.Flag("recursive").Changed {
+				if len(args) != 2 {
+					return fmt.Errorf("recursive put requires exactly one local directory")
+				}
+				jobs, err := cmd.Flags().GetInt("jobs")
+				if err != nil {
+					return err
+				}
+				return sc.putObjects(bucket, key, args[1], jobs)
+			}
 			if len(args) < 2 { // upload zero-size file
 				err = sc.putObject(bucket, key, fd)
+			} else if len(args) == 2 && args[1] == "-" { // upload from stdin
+				err = sc.putStdin(bucket, key, os.Stdin)
 			} else if len(args) == 2 { // upload one file
 				if key == "" {
 					key = filepath.Base(args[1])
 				}
-				fd, err = os.Open(args[1])
-				if err != nil {
-					return err
-				}
-				defer fd.Close()
-				err = sc.putObject(bucket, key, fd)
+				err = sc.putFile(bucket, key, args[1])
 			} else { // upload multi files
+				var total int64
 				for _, v := range args[1:] {
-					newKey := fmt.Sprintf("%s%s", key, filepath.Base(v))
-					fd, err = os.Open(v)
-					if err != nil {
-						return err
+					if fi, err := os.Stat(v); err == nil {
+						total += fi.Size()
 					}
-					err = sc.putObject(bucket, newKey, fd)
+				}
+				if sc.startProgress(total, int64(len(args)-1)) {
+					defer sc.stopProgress()
+				}
+				for _, v := range args[1:] {
+					newKey := fmt.Sprintf("%s%s", key, filepath.Base(v))
+					err = sc.putFile(bucket, newKey, v)
 					if err != nil {
-						fd.Close()
 						return err
 					}
-					fd.Close()
 				}
 			}
 			return
 		},
 	}
+	putObjectCmd.Flags().BoolP("recursive", "r", false, "upload local directory tree recursively")
+	putObjectCmd.Flags().IntP("jobs", "j", 4, "number of parallel uploads in recursive mode")
+	putObjectCmd.Flags().StringP("part-size", "", "16M", "MPU part size")
+	putObjectCmd.Flags().StringP("threshold", "", "64M", "upload with MPU if file size >= threshold")
+	putObjectCmd.Flags().IntP("concurrency", "", 4, "number of parallel MPU parts")
+	putObjectCmd.Flags().BoolP("resume", "", false, "resume MPU with checkpoint file(<local-file>.s3cli-mpu)")
+	putObjectCmd.Flags().StringP("checksum-algorithm", "", "", "additional checksum(CRC32C, SHA256) of single-part upload")
+	putObjectCmd.Flags().StringArrayP("tag", "", nil, "tag(key=value) of uploaded Objects")
+	putObjectCmd.Flags().StringP("sse", "", "", "server-side encryption(AES256, aws:kms)")
+	putObjectCmd.Flags().StringP("sse-kms-key-id", "", "", "SSE-KMS key ID(implies --sse aws:kms)")
+	putObjectCmd.Flags().StringP("sse-c-key", "", "", "SSE-C key file(32 bytes or base64)")
 	rootCmd.AddCommand(putObjectCmd)
 
+	syncCmd := &cobra.Command{
+		Use:   "sync <local-dir|bucket[/prefix]> <bucket[/prefix]|local-dir>",
+		Short: "sync local directory and Bucket/prefix",
+		Long: `sync local directory and Bucket/prefix usage:
+* sync(upload) changed files in ./dir to bucket/prefix
+	s3cli sync ./dir bucket/prefix
+* sync(download) changed Objects in bucket/prefix to ./dir
+	s3cli sync bucket/prefix ./dir
+* sync and delete extraneous destination files/Objects
+	s3cli sync --delete ./dir bucket/prefix
+* preview what sync would do
+	s3cli sync --dry-run --delete bucket/prefix ./dir
+* compare local MD5 with ETag(single-part Objects) instead of mtime
+	s3cli sync --checksum ./dir bucket/prefix
+
+* the first argument is a local-dir if it is an existing local directory
+* files/Objects with different size or newer mtime are transferred`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobs, err := cmd.Flags().GetInt("jobs")
+			if err != nil {
+				return err
+			}
+			opt := syncOptions{
+				delete:   cmd.Flag("delete").Changed,
+				dryRun:   cmd.Flag("dry-run").Changed,
+				checksum: cmd.Flag("checksum").Changed,
+				jobs:     jobs,
+			}
+			if fi, err := os.Stat(args[0]); err == nil && fi.IsDir() {
+				bucket, prefix := splitBucketObject(args[1])
+				return sc.syncUp(args[0], bucket, prefix, opt)
+			}
+			bucket, prefix := splitBucketObject(args[0])
+			return sc.syncDown(bucket, prefix, args[1], opt)
+		},
+	}
+	syncCmd.Flags().BoolP("delete", "", false, "delete extraneous files/Objects from destination")
+	syncCmd.Flags().BoolP("dry-run", "n", false, "show what would be transferred/deleted")
+	syncCmd.Flags().BoolP("checksum", "c", false, "compare MD5 with ETag instead of mtime")
+	syncCmd.Flags().IntP("jobs", "j", 4, "number of parallel transfers")
+	rootCmd.AddCommand(syncCmd)
+
 	headCmd := &cobra.Command{
 		Use:   "head <bucket/key>",
 		Short: "head Bucket/Object",
@@ -411,9 +1002,14 @@ Credential EnvVar:
 * head a Bucket
 	s3cli head bucket
 * head a Object
-	s3cli head bucket/key`,
+	s3cli head bucket/key
+* head a Object encrypted with SSE-C
+	s3cli head --sse-c-key key.bin bucket/key`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := setSSEFlags(&sc, cmd); err != nil {
+				return err
+			}
 			bucket, key := splitBucketObject(args[0])
 			if key != "" {
 				mt := cmd.Flag("mtime").Changed
@@ -425,6 +1021,7 @@ Credential EnvVar:
 	}
 	headCmd.Flags().BoolP("mtimestamp", "", false, "show Object mtimestamp")
 	headCmd.Flags().BoolP("mtime", "", false, "show Object mtime")
+	headCmd.Flags().StringP("sse-c-key", "", "", "SSE-C key file(32 bytes or base64) of the Object")
 	rootCmd.AddCommand(headCmd)
 
 	aclCmd := &cobra.Command{
@@ -492,6 +1089,73 @@ Credential EnvVar:
 	}
 	rootCmd.AddCommand(aclCmd)
 
+	tagCmd := &cobra.Command{
+		Use:   "tag",
+		Short: "get/set Bucket/Object tags",
+		Long: `get/set Bucket/Object tags usage:
+* get Bucket/Object tags
+	s3cli tag get bucket
+	s3cli tag get bucket/key
+* set(replace) tags
+	s3cli tag set bucket/key team=data env=dev
+* add tags, or replace the values of existing keys
+	s3cli tag add bucket team=data
+* remove tags of keys
+	s3cli tag remove bucket/key env
+* add tags to all Objects with prefix by 8 parallel workers
+	s3cli tag add -r -j 8 bucket/logs/ class=archive
+
+* at most 10 tags of a Object and 50 tags of a Bucket
+* removing all tags deletes the tagging of the Bucket/Object`,
+	}
+	rootCmd.AddCommand(tagCmd)
+
+	tagGetCmd := &cobra.Command{
+		Use:   "get <bucket[/key]>",
+		Short: "get Bucket/Object tags",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bucket, key := splitBucketObject(args[0])
+			return sc.tagGet(bucket, key)
+		},
+	}
+	tagCmd.AddCommand(tagGetCmd)
+
+	for _, op := range []string{tagOpSet, tagOpAdd, tagOpRemove} {
+		op := op
+		use := fmt.Sprintf("%s <bucket[/key]> <key=value> [<key=value> ...]", op)
+		short := fmt.Sprintf("%s Bucket/Object tags", op)
+		if op == tagOpRemove {
+			use = "remove <bucket[/key]> <key> [<key> ...]"
+		}
+		tagOpCmd := &cobra.Command{
+			Use:   use,
+			Short: short,
+			Args:  cobra.MinimumNArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				tags := keyTags(args[1:])
+				if op != tagOpRemove {
+					var err error
+					if tags, err = parseTags(args[1:]); err != nil {
+						return err
+					}
+				}
+				bucket, key := splitBucketObject(args[0])
+				if cmd.Flag("recursive").Changed {
+					jobs, err := cmd.Flags().GetInt("jobs")
+					if err != nil {
+						return err
+					}
+					return sc.tagObjects(bucket, key, op, tags, jobs)
+				}
+				return sc.tagKey(bucket, key, op, tags)
+			},
+		}
+		tagOpCmd.Flags().BoolP("recursive", "r", false, "tag all Objects with prefix")
+		tagOpCmd.Flags().IntP("jobs", "j", 4, "number of parallel workers in recursive mode")
+		tagCmd.AddCommand(tagOpCmd)
+	}
+
 	listObjectCmd := &cobra.Command{
 		Use:     "list [bucket[/prefix]]",
 		Aliases: []string{"ls"},
@@ -507,10 +1171,15 @@ Credential EnvVar:
 	s3cli ls bucket --start-time '2020-03-03 00:00:00' --end-time '2020-06-03 00:00:00'
 * list Objects(2020-03-03 00:00:00 < modifyTime < 2020-06-03 00:00:00) start with common prefix
 	s3cli ls bucket/prefix --start-time '2020-03-03 00:00:00' --end-time '2020-06-03 00:00:00'
+* list Objects in long format(mtime, size, storage-class, ETag, owner, key)
+	s3cli ls -l bucket/prefix
+* list Objects in long format with human readable size, and common prefixes as DIR
+	s3cli ls -l -H -d / bucket/prefix/
 `,
 		Args: cobra.RangeArgs(0, 1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			index := cmd.Flag("index").Changed
+			long := cmd.Flag("long").Changed
 			delimiter := cmd.Flag("delimiter").Value.String()
 			if len(args) == 1 { // list Objects
 				stime, err := time.Parse("2006-01-02 15:04:05", cmd.Flag("start-time").Value.String())
@@ -523,14 +1192,17 @@ Credential EnvVar:
 				}
 
 				bucket, prefix := splitBucketObject(args[0])
-				if cmd.Flag("all").Changed {
-					return sc.listAllObjects(bucket, prefix, delimiter, index, stime, etime)
-				}
 				maxKeys, err := cmd.Flags().GetInt64("maxkeys")
 				if err != nil {
 					maxKeys = 1000
 				}
 				marker := cmd.Flag("marker").Value.String()
+				if long {
+					return sc.listObjectsLong(bucket, prefix, delimiter, marker, maxKeys, cmd.Flag("all").Changed, cmd.Flag("human-readable").Changed, stime, etime)
+				}
+				if cmd.Flag("all").Changed {
+					return sc.listAllObjects(bucket, prefix, delimiter, index, stime, etime)
+				}
 				return sc.listObjects(bucket, prefix, delimiter, marker, maxKeys, index, stime, etime)
 			}
 
@@ -543,6 +1215,8 @@ Credential EnvVar:
 	listObjectCmd.Flags().StringP("delimiter", "d", "", "Object delimiter")
 	listObjectCmd.Flags().BoolP("index", "i", false, "show Object index ")
 	listObjectCmd.Flags().BoolP("all", "a", false, "list all Objects")
+	listObjectCmd.Flags().BoolP("long", "l", false, "list Objects in long format")
+	listObjectCmd.Flags().BoolP("human-readable", "H", false, "show human readable size in long format")
 	listObjectCmd.Flags().StringP("start-time", "", "2006-01-02 15:04:05", "show Objects modify-time after start-time(UTC)")
 	listObjectCmd.Flags().StringP("end-time", "", "2080-01-02 15:04:05", "show Objects modify-time before end-time(UTC)")
 	rootCmd.AddCommand(listObjectCmd)
@@ -621,6 +1295,130 @@ Credential EnvVar:
 	}
 	rootCmd.AddCommand(listVersionCmd)
 
+	duCmd := &cobra.Command{
+		Use:   "du <bucket[/prefix]>",
+		Short: "summarize Objects size",
+		Long: `summarize Objects size usage:
+* total size and count of Objects in a Bucket
+	s3cli du bucket
+* total size and count of Objects with prefix, human readable
+	s3cli du -H bucket/prefix/
+* size of each common prefix under prefix(2 levels deep)
+	s3cli du --depth 2 bucket/prefix/
+* include noncurrent versions
+	s3cli du --versions bucket/prefix/
+
+* the size is also summarized per storage class`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			depth, err := cmd.Flags().GetInt("depth")
+			if err != nil {
+				return err
+			}
+			bucket, prefix := splitBucketObject(args[0])
+			return sc.du(bucket, prefix, depth, cmd.Flag("versions").Changed, cmd.Flag("human-readable").Changed)
+		},
+	}
+	duCmd.Flags().IntP("depth", "", 0, "show size of common prefixes up to N levels(delimited by /) under prefix")
+	duCmd.Flags().BoolP("versions", "", false, "include noncurrent Object versions")
+	duCmd.Flags().BoolP("human-readable", "H", false, "show human readable size")
+	rootCmd.AddCommand(duCmd)
+
+	findCmd := &cobra.Command{
+		Use:   "find <bucket[/prefix]>",
+		Short: "find Objects",
+		Long: `find Objects usage:
+* find Objects with name(base name of key) pattern
+	s3cli find bucket/prefix --name '*.parquet'
+* find Objects between 1M and 1G, modified between 30 days and 2 days ago
+	s3cli find bucket/prefix --min-size 1M --max-size 1G --older-than 30d --newer-than 2d
+* find Objects with key regexp
+	s3cli find bucket --regex '^logs/2024-0[1-6]/.*\.log$'
+* delete matched Objects
+	s3cli find bucket/tmp/ --older-than 7d --exec delete
+* copy matched Objects to bucket2/backup/(replace the prefix tmp/)
+	s3cli find bucket/tmp/ --name '*.csv' --exec copy --dest bucket2/backup/
+* tag matched Objects
+	s3cli find bucket/tmp/ --name '*.csv' --exec tag --tag archive=true --tag owner=etl
+
+* ages are like 30d, 2w, 12h or 90m`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opt := findOptions{
+				name:    cmd.Flag("name").Value.String(),
+				maxSize: -1,
+				exec:    cmd.Flag("exec").Value.String(),
+			}
+			var err error
+			if _, err = path.Match(opt.name, ""); err != nil {
+				return fmt.Errorf("invalid name pattern %s: %w", opt.name, err)
+			}
+			if v := cmd.Flag("regex").Value.String(); v != "" {
+				if opt.regex, err = regexp.Compile(v); err != nil {
+					return fmt.Errorf("invalid regex %s: %w", v, err)
+				}
+			}
+			if v := cmd.Flag("min-size").Value.String(); v != "" {
+				if opt.minSize, err = parseSize(v); err != nil {
+					return err
+				}
+			}
+			if v := cmd.Flag("max-size").Value.String(); v != "" {
+				if opt.maxSize, err = parseSize(v); err != nil {
+					return err
+				}
+			}
+			if v := cmd.Flag("older-than").Value.String(); v != "" {
+				if opt.olderThan, err = parseAge(v); err != nil {
+					return err
+				}
+			}
+			if v := cmd.Flag("newer-than").Value.String(); v != "" {
+				if opt.newerThan, err = parseAge(v); err != nil {
+					return err
+				}
+			}
+			if err := validFindExec(opt.exec); err != nil {
+				return err
+			}
+			if opt.jobs, err = cmd.Flags().GetInt("jobs"); err != nil {
+				return err
+			}
+			switch opt.exec {
+			case findExecCopy:
+				dest := cmd.Flag("dest").Value.String()
+				if dest == "" {
+					return fmt.Errorf("--dest is required by --exec copy")
+				}
+				opt.destBucket, opt.destPrefix = splitBucketObject(dest)
+			case findExecTag:
+				tags, err := cmd.Flags().GetStringArray("tag")
+				if err != nil {
+					return err
+				}
+				if len(tags) == 0 {
+					return fmt.Errorf("--tag is required by --exec tag")
+				}
+				if opt.tags, err = parseTags(tags); err != nil {
+					return err
+				}
+			}
+			bucket, prefix := splitBucketObject(args[0])
+			return sc.find(bucket, prefix, opt)
+		},
+	}
+	findCmd.Flags().StringP("name", "", "", "shell pattern to match the base name of key")
+	findCmd.Flags().StringP("regex", "", "", "regexp to match the key")
+	findCmd.Flags().StringP("min-size", "", "", "min Object size(like 1M)")
+	findCmd.Flags().StringP("max-size", "", "", "max Object size(like 1G)")
+	findCmd.Flags().StringP("older-than", "", "", "Objects modified before the age(like 30d)")
+	findCmd.Flags().StringP("newer-than", "", "", "Objects modified within the age(like 2d)")
+	findCmd.Flags().StringP("exec", "", "", "action on matched Objects(delete, copy, tag)")
+	findCmd.Flags().StringP("dest", "", "", "destination bucket[/prefix] of --exec copy")
+	findCmd.Flags().StringArrayP("tag", "", nil, "tag(key=value) of --exec tag")
+	findCmd.Flags().IntP("jobs", "j", 4, "number of parallel copy/tag")
+	rootCmd.AddCommand(findCmd)
+
 	getObjectCmd := &cobra.Command{
 		Use:     "get <bucket/key> [destination]",
 		Aliases: []string{"download", "down"},
@@ -630,13 +1428,74 @@ Credential EnvVar:
 	s3cli get bucket/key
 * get(download) a Object to /path/to/file
 	s3cli get bucket/key /path/to/file
+* get(download) all Objects with prefix(logs/2024/) to ./out, skip existing files
+	s3cli get --recursive bucket/logs/2024/ ./out
+* get(download) all Objects with prefix(logs/2024/) to ./out, overwrite existing files
+	s3cli get --recursive -w -j 8 bucket/logs/2024/ ./out
+* get(download) a large Object in 64M ranges with 8 parallel ranges
+	s3cli get --part-size 64M --concurrency 8 bucket/key /path/to/file
+* get(download) a large Object and resume it after failure
+	s3cli get --resume bucket/key /path/to/file
+* get(download) all Objects match the wildcard pattern to ./out
+	s3cli get 'bucket/logs/2024-*/app-?.log' ./out
+* get(download) a Object to stdout
+	s3cli get bucket/key - | tar xz
+* get(download) a Object encrypted with SSE-C
+	s3cli get --sse-c-key key.bin bucket/key /path/to/file
 * presign(V4) a get(download) Object URL
-	s3cli get bucket/key --presign`,
+	s3cli get bucket/key --presign
+
+* Objects larger than --threshold are downloaded in parallel ranges
+* downloads are verified against the ETag(single-part, or MPU by s3cli with the part size metadata),
+  skip it with --no-verify
+* wildcards(*, ?, [...]) do not match /, escape them with \ in literal keys`,
 		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := setSSEFlags(&sc, cmd); err != nil {
+				return err
+			}
 			bucket, key := splitBucketObject(args[0])
+			prefix, glob := sc.splitKeyGlob(bucket, key, sc.headKey)
+			if len(args) == 2 && args[1] == "-" {
+				objRange := cmd.Flag("range").Value.String()
+				version := cmd.Flag("version").Value.String()
+				if cmd.Flag("recursive").Changed {
+					return fmt.Errorf("--recursive is not supported with stdout")
+				} else if !glob {
+					return sc.catObject(bucket, prefix, objRange, version, false)
+				} else if version != "" {
+					return fmt.Errorf("--version is not supported with wildcard pattern")
+				}
+				return sc.catGlob(bucket, key, objRange, false)
+			}
+			if cmd.Flag("recursive").Changed || glob {
+				dir := "."
+				if len(args) == 2 {
+					dir = args[1]
+				}
+				jobs, err := cmd.Flags().GetInt("jobs")
+				if err != nil {
+					return err
+				}
+				pattern := ""
+				if glob {
+					pattern = key
+				}
+				return sc.getObjects(bucket, prefix, pattern, dir, jobs, cmd.Flag("overwrite").Changed)
+			}
+			key = prefix
 			objRange := cmd.Flag("range").Value.String()
 			version := cmd.Flag("version").Value.String()
+			filename := filepath.Base(key)
+			if len(args) == 2 {
+				filename = args[1]
+			}
+			if objRange == "" && !sc.presign {
+				if err := setMpuFlags(&sc, cmd); err != nil {
+					return err
+				}
+				return sc.getFile(bucket, key, version, filename)
+			}
 			r, err := sc.getObject(bucket, key, objRange, version)
 			if err != nil {
 				return err
@@ -645,10 +1504,6 @@ Credential EnvVar:
 				return nil
 			}
 			defer r.Close()
-			filename := filepath.Base(key)
-			if len(args) == 2 {
-				filename = args[1]
-			}
 			// Create a file to write the S3 Object contents
 			fd, err := os.Create(filename)
 			if err != nil {
@@ -662,6 +1517,13 @@ Credential EnvVar:
 	getObjectCmd.Flags().StringP("range", "r", "", "Object range to download, 0-64 means [0, 64]")
 	getObjectCmd.Flags().StringP("version", "", "", "Object version ID to delete")
 	getObjectCmd.Flags().BoolP("overwrite", "w", false, "overwrite file if exist")
+	getObjectCmd.Flags().BoolP("recursive", "", false, "download all Objects with prefix to local directory")
+	getObjectCmd.Flags().IntP("jobs", "j", 4, "number of parallel downloads in recursive/wildcard mode")
+	getObjectCmd.Flags().StringP("part-size", "", "16M", "range size of parallel download")
+	getObjectCmd.Flags().StringP("threshold", "", "64M", "download in parallel ranges if Object size >= threshold")
+	getObjectCmd.Flags().IntP("concurrency", "", 4, "number of parallel ranges")
+	getObjectCmd.Flags().BoolP("resume", "", false, "resume parallel download with checkpoint file(<local-file>.s3cli-get)")
+	getObjectCmd.Flags().StringP("sse-c-key", "", "", "SSE-C key file(32 bytes or base64) of the Object")
 	rootCmd.AddCommand(getObjectCmd)
 
 	catObjectCmd := &cobra.Command{
@@ -669,17 +1531,38 @@ Credential EnvVar:
 		Short: "cat Object",
 		Long: `cat Object contents usage:
 * cat a Object
-	s3cli cat bucket/key`,
+	s3cli cat bucket/key
+* cat all Objects match the wildcard pattern
+	s3cli cat 'bucket/logs/2024-01-0?/app.log'
+* cat a gzip/zstd/bzip2 compressed Object decompressed
+	s3cli cat --decompress bucket/logs/app.log.gz | grep ERROR
+
+* --decompress detects the format(gzip, zstd, bzip2) from the magic bytes,
+  uncompressed Objects are printed as is`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			objRange := cmd.Flag("range").Value.String()
 			version := cmd.Flag("version").Value.String()
+			decompress := cmd.Flag("decompress").Changed
+			if decompress && objRange != "" {
+				return fmt.Errorf("--range is not supported with --decompress")
+			}
+			if err := setSSEFlags(&sc, cmd); err != nil {
+				return err
+			}
 			bucket, key := splitBucketObject(args[0])
-			return sc.catObject(bucket, key, objRange, version)
+			if prefix, glob := sc.splitKeyGlob(bucket, key, sc.headKey); !glob {
+				return sc.catObject(bucket, prefix, objRange, version, decompress)
+			} else if version != "" {
+				return fmt.Errorf("--version is not supported with wildcard pattern")
+			}
+			return sc.catGlob(bucket, key, objRange, decompress)
 		},
 	}
 	catObjectCmd.Flags().StringP("range", "r", "", "Object range to cat, 0-64 means [0, 64]")
 	catObjectCmd.Flags().StringP("version", "", "", "version to cat")
+	catObjectCmd.Flags().BoolP("decompress", "z", false, "decompress gzip/zstd/bzip2 Object contents")
+	catObjectCmd.Flags().StringP("sse-c-key", "", "", "SSE-C key file(32 bytes or base64) of the Object")
 	rootCmd.AddCommand(catObjectCmd)
 
 	renameObjectCmd := &cobra.Command{
@@ -690,16 +1573,30 @@ Credential EnvVar:
 * specify destination key
 	s3cli mv bucket/key1 bucket2/key2
 * default destionation key
-	s3cli mv bucket/key1 bucket2`,
+	s3cli mv bucket/key1 bucket2
+* move all Objects with prefix(old/) to prefix(new/)
+	s3cli mv -r -j 8 bucket/old/ bucket/new/
+
+* the Object is copied(server-side), verified and then the source is deleted`,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			bucket, key := splitBucketObject(args[1])
+			if cmd.Flag("recursive").Changed {
+				jobs, err := cmd.Flags().GetInt("jobs")
+				if err != nil {
+					return err
+				}
+				srcBucket, prefix := splitBucketObject(args[0])
+				return sc.moveObjects(srcBucket, prefix, bucket, key, jobs)
+			}
 			if key == "" {
 				_, key = splitBucketObject(args[0])
 			}
 			return sc.renameObject(args[0], bucket, key)
 		},
 	}
+	renameObjectCmd.Flags().BoolP("recursive", "r", false, "move all Objects with prefix")
+	renameObjectCmd.Flags().IntP("jobs", "j", 4, "number of parallel moves in recursive mode")
 	rootCmd.AddCommand(renameObjectCmd)
 
 	copyObjectCmd := &cobra.Command{
@@ -710,16 +1607,91 @@ Credential EnvVar:
 * spedify destination key
 	s3cli copy bucket/key1 bucket2/key2
 * default destionation key
-	s3cli copy bucket/key1 bucket2`,
+	s3cli copy bucket/key1 bucket2
+* copy all Objects match the wildcard pattern to bucket2/archive/
+	s3cli copy 'bucket/logs/2024-*/app-?.log' bucket2/archive/
+* copy a large Object in 256M parts with 8 parallel UploadPartCopy
+	s3cli copy --threshold 1G --part-size 256M --concurrency 8 bucket/dump.sql bucket2
+
+* copy all Objects with prefix(logs/) to bucket2/archive/ with 8 parallel copies
+	s3cli copy -r -j 8 bucket/logs/ bucket2/archive/
+* copy from another S3 endpoint(GET from source endpoint and PUT on -e endpoint)
+	s3cli copy --src-endpoint http://10.0.0.2:9020 --src-ak ak --src-sk sk -r bucket/logs/ bucket2/logs/
+* copy a SSE-C Object and encrypt the destination with SSE-KMS
+	s3cli copy --sse-c-source-key key.bin --sse aws:kms bucket/key bucket2/key
+
+* Objects larger than --threshold(at most 5G) are copied in parallel parts, keep metadata and content type
+* with --src-endpoint Objects are streamed through memory(at most --concurrency parts), --threshold and
+  --part-size are the MPU threshold and part size of upload(default 64M and 16M)`,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := setMpuFlags(&sc, cmd); err != nil {
+				return err
+			}
+			if err := setSSEFlags(&sc, cmd); err != nil {
+				return err
+			}
+			jobs, err := cmd.Flags().GetInt("jobs")
+			if err != nil {
+				return err
+			}
+			srcBucket, srcKey := splitBucketObject(args[0])
 			bucket, key := splitBucketObject(args[1])
-			if key == "" {
-				_, key = splitBucketObject(args[0])
+			var src *S3Cli // source of cross-endpoint streaming copy
+			if endpoint := cmd.Flag("src-endpoint").Value.String(); endpoint != "" {
+				if !cmd.Flag("threshold").Changed {
+					sc.threshold = defaultThreshold
+				}
+				if !cmd.Flag("part-size").Changed {
+					sc.partSize = defaultPartSize
+				}
+				client, err := newEndpointClient(&sc, endpoint, cmd.Flag("src-ak").Value.String(), cmd.Flag("src-sk").Value.String())
+				if err != nil {
+					return err
+				}
+				src = &S3Cli{endpoint: endpoint, Client: client, sse: sseOptions{customerKey: sc.sse.sourceKey}}
+			}
+			var prefix string
+			var glob bool
+			if src != nil {
+				prefix, glob = src.splitKeyGlob(srcBucket, srcKey, src.headKey)
+			} else {
+				prefix, glob = sc.splitKeyGlob(srcBucket, srcKey, sc.headSource)
+			}
+			pattern := ""
+			if glob {
+				pattern = srcKey
+			}
+			recursive := cmd.Flag("recursive").Changed || glob
+			if key == "" && !recursive {
+				key = prefix
+			}
+
+			if src != nil {
+				if recursive {
+					return sc.streamObjects(src, srcBucket, prefix, pattern, bucket, key, jobs)
+				}
+				return sc.streamObject(src, srcBucket+"/"+prefix, bucket, key)
 			}
-			return sc.copyObject(args[0], bucket, key)
+
+			if recursive {
+				return sc.copyObjects(srcBucket, prefix, pattern, bucket, key, jobs)
+			}
+			return sc.copyObject(srcBucket+"/"+prefix, bucket, key)
 		},
 	}
+	copyObjectCmd.Flags().BoolP("recursive", "r", false, "copy all Objects with prefix")
+	copyObjectCmd.Flags().IntP("jobs", "j", 4, "number of parallel copies in recursive/wildcard mode")
+	copyObjectCmd.Flags().StringP("src-endpoint", "", "", "source S3 endpoint(http://host:port) if it is not the -e endpoint")
+	copyObjectCmd.Flags().StringP("src-ak", "", "", "access key of source endpoint(default the same as --ak)")
+	copyObjectCmd.Flags().StringP("src-sk", "", "", "secret key of source endpoint(default the same as --sk)")
+	copyObjectCmd.Flags().StringP("part-size", "", "256M", "part size of parallel copy")
+	copyObjectCmd.Flags().StringP("threshold", "", "5G", "copy in parallel parts if Object size >= threshold")
+	copyObjectCmd.Flags().IntP("concurrency", "", 4, "number of parallel parts")
+	copyObjectCmd.Flags().StringP("sse", "", "", "server-side encryption(AES256, aws:kms) of destination")
+	copyObjectCmd.Flags().StringP("sse-kms-key-id", "", "", "SSE-KMS key ID of destination(implies --sse aws:kms)")
+	copyObjectCmd.Flags().StringP("sse-c-key", "", "", "SSE-C key file(32 bytes or base64) of destination")
+	copyObjectCmd.Flags().StringP("sse-c-source-key", "", "", "SSE-C key file(32 bytes or base64) of source")
 	rootCmd.AddCommand(copyObjectCmd)
 
 	deleteObjectCmd := &cobra.Command{
@@ -732,12 +1704,21 @@ Credential EnvVar:
 * delete a Object
 	s3cli delete bucket/key
 * delete all Objects with same Prefix
-	s3cli delete bucket/prefix -x`,
+	s3cli delete bucket/prefix -x
+* delete all Objects match the wildcard pattern
+	s3cli delete 'bucket/tmp/*.tmp'`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			prefixMode := cmd.Flag("prefix").Changed
 			force := cmd.Flag("force").Changed
 			bucket, key := splitBucketObject(args[0])
+			prefix, glob := sc.splitKeyGlob(bucket, key, sc.headKey)
+			if glob && prefixMode {
+				return fmt.Errorf("wildcard pattern can not be used with --prefix")
+			} else if glob {
+				return sc.deleteGlob(bucket, key)
+			}
+			key = prefix
 			if prefixMode {
 				return sc.deleteObjects(bucket, key)
 			} else if key != "" {
@@ -833,13 +1814,29 @@ Credential EnvVar:
 	}
 	mpuCmd.AddCommand(mpuListCmd)
 
+	mpuPartsCmd := &cobra.Command{
+		Use:   "parts <bucket/key> <UploadId>",
+		Short: "list MPU parts",
+		Long: `list uploaded parts of a mutiPartUpload usage:
+* list MPU parts(part-num ETag size)
+	s3cli mpu parts bucket/key UploadId`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bucket, key := splitBucketObject(args[0])
+			return sc.mpuParts(bucket, key, args[1])
+		},
+	}
+	mpuCmd.AddCommand(mpuPartsCmd)
+
 	mpuCompleteCmd := &cobra.Command{
-		Use:   "complete <bucket/key> <UploadId> <part-etag> [<part-etag> ...]",
+		Use:   "complete <bucket/key> <UploadId> [<part-etag> ...]",
 		Short: "complete a MPU request",
 		Long: `complete a mutiPartUpload request usage:
-* complete a MPU request
-	s3cli mpu complete bucket/key UploadId etag01 etag02 etag03`,
-		Args: cobra.MinimumNArgs(3),
+* complete a MPU request with part1, part2 and part3 ETags
+	s3cli mpu complete bucket/key UploadId etag01 etag02 etag03
+* complete a MPU request with all uploaded parts(listed from server)
+	s3cli mpu complete bucket/key UploadId`,
+		Args: cobra.MinimumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			bucket, key := splitBucketObject(args[0])
 			etags := make([]string, len(args)-2)
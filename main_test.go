@@ -2,13 +2,24 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io/ioutil"
 	"log"
 	mand "math/rand"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/johannesboyne/gofakes3"
 	"github.com/johannesboyne/gofakes3/backend/s3mem"
@@ -33,14 +44,157 @@ func setEnv() error {
 	return os.Setenv("AWS_SECRET_KEY", "zuf+tfteSlswRu7BJ86wekitnifILbZam1KYY3TG")
 }
 
+// writeCredentialsFile write a credentials file(read by setCredentials) for endpoint
+func writeCredentialsFile(endpoint string) (string, error) {
+	creds, err := json.Marshal(&Credentials{
+		Endpoint:    endpoint,
+		AccessKeyId: s3cliTest.ak,
+		SecretKeyId: s3cliTest.sk,
+	})
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(&CredentialsFile{Credentials: string(creds)})
+	if err != nil {
+		return "", err
+	}
+	fd, err := ioutil.TempFile("", "s3cli-credentials")
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	_, err = fd.Write(data)
+	return fd.Name(), err
+}
+
+// uploadPartCopyHandler serve UploadPartCopy(not supported by gofakes3) as UploadPart with the source range
+func uploadPartCopyHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source := r.Header.Get("X-Amz-Copy-Source")
+		if r.Method != http.MethodPut || source == "" || r.URL.Query().Get("partNumber") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		source, err := url.PathUnescape(source)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		bucket, key := splitBucketObject(strings.TrimPrefix(source, "/"))
+		rng := &gofakes3.ObjectRangeRequest{}
+		if _, err := fmt.Sscanf(r.Header.Get("X-Amz-Copy-Source-Range"), "bytes=%d-%d", &rng.Start, &rng.End); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		obj, err := s3Backend.GetObject(bucket, key, rng)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		data, err := ioutil.ReadAll(obj.Contents)
+		obj.Contents.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		r.Header.Del("X-Amz-Copy-Source")
+		r.Header.Del("X-Amz-Copy-Source-Range")
+		r.Header.Set("Content-Length", strconv.Itoa(len(data)))
+		r.ContentLength = int64(len(data))
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+		if rec.Code != http.StatusOK {
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+			return
+		}
+		fmt.Fprintf(w, "<CopyPartResult><ETag>%s</ETag></CopyPartResult>", html.EscapeString(rec.Header().Get("ETag")))
+	})
+}
+
+// testTags tags stored by taggingHandler, by bucket[/key]
+var testTags = struct {
+	sync.Mutex
+	m map[string][]*s3.Tag
+}{m: map[string][]*s3.Tag{}}
+
+// taggingHandler serve Bucket/Object tagging(not supported by gofakes3) in memory,
+// and record the x-amz-tagging header of uploads
+func taggingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Trim(r.URL.Path, "/")
+		if _, ok := r.URL.Query()["tagging"]; !ok {
+			if v := r.Header.Get("X-Amz-Tagging"); v != "" {
+				values, err := url.ParseQuery(v)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				var tags []*s3.Tag
+				for k := range values {
+					tags = append(tags, &s3.Tag{Key: aws.String(k), Value: aws.String(values.Get(k))})
+				}
+				testTags.Lock()
+				testTags.m[name] = tags
+				testTags.Unlock()
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		testTags.Lock()
+		defer testTags.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			tags, ok := testTags.m[name]
+			if !ok && !strings.Contains(name, "/") {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, "<Error><Code>NoSuchTagSet</Code><Message>The TagSet does not exist</Message></Error>")
+				return
+			}
+			data, err := xml.Marshal(struct {
+				XMLName xml.Name  `xml:"Tagging"`
+				Tags    []*s3.Tag `xml:"TagSet>Tag"`
+			}{Tags: tags})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Write(data)
+		case http.MethodPut:
+			tagging := struct {
+				Tags []*s3.Tag `xml:"TagSet>Tag"`
+			}{}
+			if err := xml.NewDecoder(r.Body).Decode(&tagging); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			testTags.m[name] = tagging.Tags
+		case http.MethodDelete:
+			delete(testTags.m, name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
 func TestMain(m *testing.M) {
 	mand.Seed(time.Now().UTC().UnixNano())
 	// init fake s3
 	s3Backend = s3mem.New()
 	faker := gofakes3.New(s3Backend)
-	ts := httptest.NewServer(faker.Server())
+	ts := httptest.NewServer(taggingHandler(uploadPartCopyHandler(faker.Server())))
 	defer ts.Close()
 	s3cliTest.endpoint = ts.URL
+	credentialsFile, err := writeCredentialsFile(ts.URL)
+	if err != nil {
+		log.Fatal("write credentials file error: ", err)
+		os.Exit(1)
+	}
+	defer os.Remove(credentialsFile)
+	if err := os.Setenv("CREDENTIALS_FILE_PATH", credentialsFile); err != nil {
+		log.Fatal("set CREDENTIALS_FILE_PATH error: ", err)
+		os.Exit(1)
+	}
 	client, err := newS3Client(&s3cliTest)
 	if err != nil {
 		log.Fatal("newS3Client", err)
@@ -78,3 +232,61 @@ func Test_splitBucketObject(t *testing.T) {
 		}
 	}
 }
+
+func Test_parseSize(t *testing.T) {
+	cases := map[string]int64{
+		"0":     0,
+		"1024":  1024,
+		"64K":   64 << 10,
+		"16M":   16 << 20,
+		"16mb":  16 << 20,
+		"5GiB":  5 << 30,
+		"1T":    1 << 40,
+		" 2g ":  2 << 30,
+		"100KB": 100 << 10,
+	}
+	for k, v := range cases {
+		n, err := parseSize(k)
+		if err != nil || n != v {
+			t.Errorf("expect: %d, got: %d, %v", v, n, err)
+		}
+	}
+	for _, v := range []string{"", "M", "-1", "1X", "1.5M"} {
+		if _, err := parseSize(v); err == nil {
+			t.Errorf("expect error for size %q", v)
+		}
+	}
+}
+
+func Test_parseAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30d":  30 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"12h":  12 * time.Hour,
+		"90m":  90 * time.Minute,
+		"1.5d": 36 * time.Hour,
+	}
+	for k, v := range cases {
+		d, err := parseAge(k)
+		if err != nil || d != v {
+			t.Errorf("expect: %s, got: %s, %v", v, d, err)
+		}
+	}
+	for _, v := range []string{"", "d", "-1d", "1y"} {
+		if _, err := parseAge(v); err == nil {
+			t.Errorf("expect error for age %q", v)
+		}
+	}
+}
+
+func Test_parseTags(t *testing.T) {
+	tags, err := parseTags([]string{"k1=v1", "k2=", "k3=a=b"})
+	if err != nil || len(tags) != 3 || *tags[1].Value != "" || *tags[2].Value != "a=b" {
+		t.Errorf("unexpected tags: %v, %v", tags, err)
+	}
+	for _, v := range []string{"k1", "=v1"} {
+		if _, err := parseTags([]string{v}); err == nil {
+			t.Errorf("expect error for tag %q", v)
+		}
+	}
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gopkg.in/yaml.v2"
+)
+
+// output formats of --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// validOutput check the --output flag value
+func validOutput(output string) error {
+	switch output {
+	case outputText, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid output format: %s(text, json, yaml)", output)
+}
+
+// printOutput print v in JSON/YAML output format, or call text() in text output format
+func (sc *S3Cli) printOutput(v interface{}, text func()) error {
+	switch sc.output {
+	case outputJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case outputYAML:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		// keep the JSON field names and order
+		ms := yaml.MapSlice{}
+		if err := yaml.Unmarshal(data, &ms); err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal(ms); err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	default:
+		text()
+	}
+	return nil
+}
+
+// textOutput report whether output format is text
+func (sc *S3Cli) textOutput() bool {
+	return sc.output == "" || sc.output == outputText
+}
+
+// bucketOutput a Bucket
+type bucketOutput struct {
+	Name         string    `json:"name"`
+	CreationDate time.Time `json:"creationDate"`
+}
+
+// bucketListOutput output of bucket list
+type bucketListOutput struct {
+	Owner   string         `json:"owner,omitempty"`
+	Buckets []bucketOutput `json:"buckets"`
+}
+
+// bucketHeadOutput output of bucket head
+type bucketHeadOutput struct {
+	Bucket string `json:"bucket"`
+}
+
+// grantOutput a ACL grant
+type grantOutput struct {
+	Grantee    string `json:"grantee"`
+	Type       string `json:"type"`
+	Permission string `json:"permission"`
+}
+
+// aclOutput output of Bucket/Object ACL
+type aclOutput struct {
+	Bucket string        `json:"bucket"`
+	Key    string        `json:"key,omitempty"`
+	Owner  string        `json:"owner"`
+	Grants []grantOutput `json:"grants"`
+}
+
+// policyOutput output of Bucket Policy
+type policyOutput struct {
+	Bucket string `json:"bucket"`
+	Policy string `json:"policy"`
+}
+
+// websiteOutput output of Bucket website
+type websiteOutput struct {
+	Bucket                string        `json:"bucket"`
+	IndexDocument         string        `json:"indexDocument,omitempty"`
+	ErrorDocument         string        `json:"errorDocument,omitempty"`
+	RedirectAllRequestsTo string        `json:"redirectAllRequestsTo,omitempty"`
+	RoutingRules          []routingRule `json:"routingRules"`
+}
+
+// versioningOutput output of Bucket versioning
+type versioningOutput struct {
+	Bucket    string `json:"bucket"`
+	Status    string `json:"status"`
+	MFADelete string `json:"mfaDelete,omitempty"`
+}
+
+// objectOutput a Object in list output
+type objectOutput struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	ETag         string    `json:"etag"`
+	StorageClass string    `json:"storageClass,omitempty"`
+	Owner        string    `json:"owner,omitempty"`
+}
+
+// listOutput output of list Objects
+type listOutput struct {
+	Bucket         string         `json:"bucket"`
+	Prefix         string         `json:"prefix"`
+	Delimiter      string         `json:"delimiter,omitempty"`
+	CommonPrefixes []string       `json:"commonPrefixes"`
+	Objects        []objectOutput `json:"objects"`
+	IsTruncated    bool           `json:"isTruncated"`
+	NextMarker     string         `json:"nextMarker,omitempty"`
+}
+
+// headObjectOutput output of head Object
+type headObjectOutput struct {
+	Bucket       string            `json:"bucket"`
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	LastModified time.Time         `json:"lastModified"`
+	ETag         string            `json:"etag"`
+	ContentType  string            `json:"contentType,omitempty"`
+	VersionID    string            `json:"versionId,omitempty"`
+	StorageClass string            `json:"storageClass,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// versionOutput a Object version or delete marker
+type versionOutput struct {
+	Key          string    `json:"key"`
+	VersionID    string    `json:"versionId"`
+	IsLatest     bool      `json:"isLatest"`
+	DeleteMarker bool      `json:"deleteMarker"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	ETag         string    `json:"etag,omitempty"`
+}
+
+// listVersionsOutput output of list Object versions
+type listVersionsOutput struct {
+	Bucket      string          `json:"bucket"`
+	Prefix      string          `json:"prefix"`
+	Versions    []versionOutput `json:"versions"`
+	IsTruncated bool            `json:"isTruncated"`
+}
+
+// uploadOutput a Multi-Part-Upload
+type uploadOutput struct {
+	Bucket    string    `json:"bucket,omitempty"`
+	Key       string    `json:"key"`
+	UploadID  string    `json:"uploadId"`
+	Initiated time.Time `json:"initiated,omitempty"`
+}
+
+// listUploadsOutput output of list Multi-Part-Uploads
+type listUploadsOutput struct {
+	Bucket  string         `json:"bucket"`
+	Prefix  string         `json:"prefix"`
+	Uploads []uploadOutput `json:"uploads"`
+}
+
+// partOutput a Multi-Part-Upload part
+type partOutput struct {
+	PartNumber   int64     `json:"partNumber"`
+	ETag         string    `json:"etag"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// listPartsOutput output of list Multi-Part-Upload parts
+type listPartsOutput struct {
+	Bucket   string       `json:"bucket"`
+	Key      string       `json:"key"`
+	UploadID string       `json:"uploadId"`
+	Parts    []partOutput `json:"parts"`
+}
+
+// objectWriteOutput output of put/copy/complete-MPU Object
+type objectWriteOutput struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	ETag      string `json:"etag"`
+	VersionID string `json:"versionId,omitempty"`
+}
+
+// transferOutput the result of one file/Object transfer
+type transferOutput struct {
+	Source string `json:"source"`
+	Dest   string `json:"dest"`
+	Size   int64  `json:"size"`
+	Status string `json:"status"` // ok, skip or failed
+	Error  string `json:"error,omitempty"`
+}
+
+// transferSummaryOutput output of recursive put/get
+type transferSummaryOutput struct {
+	Transfers   []transferOutput `json:"transfers"`
+	Transferred int              `json:"transferred"`
+	Bytes       int64            `json:"bytes"`
+	Skipped     int              `json:"skipped"`
+	Failed      int              `json:"failed"`
+}
+
+// syncActionOutput the result of one sync action
+type syncActionOutput struct {
+	Op     string `json:"op"` // upload, download or delete
+	Source string `json:"source,omitempty"`
+	Dest   string `json:"dest"`
+	Status string `json:"status"` // ok, failed or dry-run
+	Error  string `json:"error,omitempty"`
+}
+
+// syncOutput output of sync
+type syncOutput struct {
+	DryRun      bool               `json:"dryRun"`
+	Actions     []syncActionOutput `json:"actions"`
+	Transferred int                `json:"transferred"`
+	Deleted     int                `json:"deleted"`
+	Failed      int                `json:"failed"`
+}
+
+// findObjectOutput a matched Object of find, and the result of --exec
+type findObjectOutput struct {
+	objectOutput
+	Status string `json:"status,omitempty"` // ok or failed
+	Error  string `json:"error,omitempty"`
+}
+
+// findOutput output of find
+type findOutput struct {
+	Bucket  string             `json:"bucket"`
+	Prefix  string             `json:"prefix"`
+	Exec    string             `json:"exec,omitempty"`
+	Objects []findObjectOutput `json:"objects"`
+	Matched int                `json:"matched"`
+	Failed  int                `json:"failed"`
+}
+
+// duUsageOutput the total size and count of Objects(versions)
+type duUsageOutput struct {
+	Size              int64 `json:"size"`
+	Objects           int64 `json:"objects"`
+	NoncurrentSize    int64 `json:"noncurrentSize,omitempty"`
+	NoncurrentObjects int64 `json:"noncurrentObjects,omitempty"`
+}
+
+// duPrefixOutput usage of a common prefix
+type duPrefixOutput struct {
+	Prefix string `json:"prefix"`
+	duUsageOutput
+}
+
+// duClassOutput usage of a storage class
+type duClassOutput struct {
+	StorageClass string `json:"storageClass"`
+	duUsageOutput
+}
+
+// duOutput output of du
+type duOutput struct {
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix"`
+	Versions bool   `json:"versions"`
+	duUsageOutput
+	StorageClasses []duClassOutput  `json:"storageClasses"`
+	Prefixes       []duPrefixOutput `json:"prefixes,omitempty"`
+}
+
+// lifecycleOutput output of bucket lifecycle
+type lifecycleOutput struct {
+	Bucket string          `json:"bucket"`
+	Rules  []lifecycleRule `json:"rules"`
+}
+
+// encryptionOutput output of Bucket default encryption
+type encryptionOutput struct {
+	Bucket           string `json:"bucket"`
+	SSEAlgorithm     string `json:"sseAlgorithm"`
+	KMSKeyID         string `json:"kmsKeyId,omitempty"`
+	BucketKeyEnabled bool   `json:"bucketKeyEnabled"`
+}
+
+// tagOutput output of Bucket/Object tags
+type tagOutput struct {
+	Bucket string            `json:"bucket"`
+	Key    string            `json:"key,omitempty"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// tagResultOutput the result of tagging one Object
+type tagResultOutput struct {
+	Key    string `json:"key"`
+	Status string `json:"status"` // ok or failed
+	Error  string `json:"error,omitempty"`
+}
+
+// tagObjectsOutput output of recursive tag
+type tagObjectsOutput struct {
+	Bucket  string            `json:"bucket"`
+	Prefix  string            `json:"prefix"`
+	Op      string            `json:"op"` // set, add or remove
+	Objects []tagResultOutput `json:"objects"`
+	Tagged  int               `json:"tagged"`
+	Failed  int               `json:"failed"`
+}
+
+// corsOutput output of bucket cors
+type corsOutput struct {
+	Bucket string     `json:"bucket"`
+	Rules  []corsRule `json:"rules"`
+}
+
+// corsTestOutput the result of a simulated preflight request
+type corsTestOutput struct {
+	Bucket   string            `json:"bucket"`
+	Origin   string            `json:"origin"`
+	Method   string            `json:"method"`
+	Headers  []string          `json:"headers"`
+	Allowed  bool              `json:"allowed"`
+	Rule     int               `json:"rule,omitempty"` // 1 based index of the matched rule
+	RuleID   string            `json:"ruleId,omitempty"`
+	Reasons  []string          `json:"reasons"`
+	Response map[string]string `json:"response,omitempty"`
+}
+
+// humanSize format size in human readable(1024 based) form, like ls -h
+func humanSize(size int64) string {
+	const units = "KMGTPE"
+	if size < 1024 {
+		return strconv.FormatInt(size, 10)
+	}
+	f := float64(size)
+	i := -1
+	for f >= 1024 && i < len(units)-1 {
+		f /= 1024
+		i++
+	}
+	if f < 10 {
+		return fmt.Sprintf("%.1f%c", f, units[i])
+	}
+	return fmt.Sprintf("%.0f%c", f, units[i])
+}
+
+// trimETag remove the quotes of a ETag
+func trimETag(etag *string) string {
+	return strings.Trim(aws.StringValue(etag), `"`)
+}
+
+// ownerName return the display name(or ID) of a owner
+func ownerName(owner *s3.Owner) string {
+	if owner == nil {
+		return ""
+	}
+	if name := aws.StringValue(owner.DisplayName); name != "" {
+		return name
+	}
+	return aws.StringValue(owner.ID)
+}
+
+// newObjectOutput convert a listed Object
+func newObjectOutput(obj *s3.Object) objectOutput {
+	return objectOutput{
+		Key:          aws.StringValue(obj.Key),
+		Size:         aws.Int64Value(obj.Size),
+		LastModified: aws.TimeValue(obj.LastModified),
+		ETag:         trimETag(obj.ETag),
+		StorageClass: aws.StringValue(obj.StorageClass),
+		Owner:        ownerName(obj.Owner),
+	}
+}
+
+// newACLOutput convert Bucket/Object ACL
+func newACLOutput(bucket, key string, owner *s3.Owner, grants []*s3.Grant) aclOutput {
+	out := aclOutput{
+		Bucket: bucket,
+		Key:    key,
+		Owner:  ownerName(owner),
+		Grants: []grantOutput{},
+	}
+	for _, g := range grants {
+		grant := grantOutput{Permission: aws.StringValue(g.Permission)}
+		if g.Grantee != nil {
+			grant.Type = aws.StringValue(g.Grantee.Type)
+			switch {
+			case g.Grantee.URI != nil:
+				grant.Grantee = aws.StringValue(g.Grantee.URI)
+			case g.Grantee.EmailAddress != nil:
+				grant.Grantee = aws.StringValue(g.Grantee.EmailAddress)
+			case g.Grantee.DisplayName != nil:
+				grant.Grantee = aws.StringValue(g.Grantee.DisplayName)
+			default:
+				grant.Grantee = aws.StringValue(g.Grantee.ID)
+			}
+		}
+		out.Grants = append(out.Grants, grant)
+	}
+	return out
+}
+
+// newPartOutput convert a Multi-Part-Upload part
+func newPartOutput(p *s3.Part) partOutput {
+	return partOutput{
+		PartNumber:   aws.Int64Value(p.PartNumber),
+		ETag:         trimETag(p.ETag),
+		Size:         aws.Int64Value(p.Size),
+		LastModified: aws.TimeValue(p.LastModified),
+	}
+}
+
+// newListOutput convert listed CommonPrefixes and Objects(modified between startTime and endTime)
+func newListOutput(bucket, prefix, delimiter string, prefixes []*s3.CommonPrefix, objects []*s3.Object, startTime, endTime time.Time) listOutput {
+	out := listOutput{
+		Bucket:         bucket,
+		Prefix:         prefix,
+		Delimiter:      delimiter,
+		CommonPrefixes: []string{},
+		Objects:        []objectOutput{},
+	}
+	out.add(prefixes, objects, startTime, endTime)
+	return out
+}
+
+// add listed CommonPrefixes and Objects(modified between startTime and endTime)
+func (out *listOutput) add(prefixes []*s3.CommonPrefix, objects []*s3.Object, startTime, endTime time.Time) {
+	for _, p := range prefixes {
+		out.CommonPrefixes = append(out.CommonPrefixes, aws.StringValue(p.Prefix))
+	}
+	for _, obj := range objects {
+		if obj.LastModified.Before(startTime) || obj.LastModified.After(endTime) {
+			continue
+		}
+		out.Objects = append(out.Objects, newObjectOutput(obj))
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gopkg.in/yaml.v2"
+)
+
+func Test_validOutput(t *testing.T) {
+	for _, v := range []string{outputText, outputJSON, outputYAML} {
+		if err := validOutput(v); err != nil {
+			t.Errorf("validOutput %s failed: %s", v, err)
+		}
+	}
+	if err := validOutput("xml"); err == nil {
+		t.Errorf("expect error for output format xml")
+	}
+}
+
+func Test_newListOutput(t *testing.T) {
+	now := time.Now()
+	objects := []*s3.Object{
+		{Key: aws.String("old"), Size: aws.Int64(1), LastModified: aws.Time(now.Add(-time.Hour)), ETag: aws.String(`"etag1"`)},
+		{Key: aws.String("new"), Size: aws.Int64(2), LastModified: aws.Time(now), ETag: aws.String(`"etag2"`)},
+	}
+	prefixes := []*s3.CommonPrefix{{Prefix: aws.String("dir/")}}
+	out := newListOutput(testBucketName, "", "/", prefixes, objects, now.Add(-time.Minute), now.Add(time.Minute))
+	if len(out.CommonPrefixes) != 1 || out.CommonPrefixes[0] != "dir/" {
+		t.Errorf("unexpected commonPrefixes: %v", out.CommonPrefixes)
+	}
+	if len(out.Objects) != 1 || out.Objects[0].Key != "new" || out.Objects[0].ETag != "etag2" {
+		t.Errorf("unexpected objects: %+v", out.Objects)
+	}
+}
+
+// captureStdout return what f prints to stdout
+func captureStdout(t *testing.T, f func() error) []byte {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe failed: %s", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	data := make(chan []byte)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		data <- b
+	}()
+	err = f()
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return <-data
+}
+
+// decodeOutput decode JSON or YAML output to a map
+func decodeOutput(t *testing.T, output string, data []byte) map[string]interface{} {
+	m := map[string]interface{}{}
+	var err error
+	if output == outputJSON {
+		err = json.Unmarshal(data, &m)
+	} else {
+		err = yaml.Unmarshal(data, &m)
+	}
+	if err != nil {
+		t.Fatalf("invalid %s output %q: %s", output, data, err)
+	}
+	return m
+}
+
+func Test_printOutput(t *testing.T) {
+	for _, output := range []string{outputJSON, outputYAML} {
+		sc := s3cliTest
+		sc.output = output
+
+		m := decodeOutput(t, output, captureStdout(t, sc.bucketList))
+		if buckets, ok := m["buckets"].([]interface{}); !ok || !strings.Contains(fmt.Sprint(buckets), testBucketName) {
+			t.Errorf("bucketList %s expect bucket %s, got: %v", output, testBucketName, m)
+		}
+
+		m = decodeOutput(t, output, captureStdout(t, func() error {
+			return sc.headObject(testBucketName, testObjectKey, false, false)
+		}))
+		if m["bucket"] != testBucketName || m["key"] != testObjectKey || fmt.Sprint(m["size"]) != strconv.Itoa(len(testObjectContent)) {
+			t.Errorf("headObject %s unexpected output: %v", output, m)
+		}
+
+		m = decodeOutput(t, output, captureStdout(t, func() error {
+			return sc.listObjects(testBucketName, "", "/", "", 1000, false, time.Time{}, time.Now().Add(time.Hour))
+		}))
+		if objects, ok := m["objects"].([]interface{}); m["bucket"] != testBucketName || !ok || !strings.Contains(fmt.Sprint(objects), testObjectKey) {
+			t.Errorf("listObjects %s unexpected output: %v", output, m)
+		}
+
+		m = decodeOutput(t, output, captureStdout(t, func() error {
+			return sc.listAllObjectsV2(testBucketName, "", "/", false, false, time.Time{}, time.Now().Add(time.Hour))
+		}))
+		if objects, ok := m["objects"].([]interface{}); m["bucket"] != testBucketName || !ok || !strings.Contains(fmt.Sprint(objects), testObjectKey) {
+			t.Errorf("listAllObjectsV2 %s unexpected output: %v", output, m)
+		}
+
+		m = decodeOutput(t, output, captureStdout(t, func() error {
+			return sc.getObjectACL(testBucketName, testObjectKey)
+		}))
+		if grants, ok := m["grants"].([]interface{}); m["bucket"] != testBucketName || m["key"] != testObjectKey || !ok {
+			t.Errorf("getObjectACL %s unexpected output: %v, grants: %v", output, m, grants)
+		}
+	}
+}
+
+func Test_humanSize(t *testing.T) {
+	cases := map[int64]string{
+		0:              "0",
+		1023:           "1023",
+		1024:           "1.0K",
+		1536:           "1.5K",
+		10 << 20:       "10M",
+		5 << 30:        "5.0G",
+		1<<40 + 1<<39:  "1.5T",
+		1024 * 1 << 50: "1.0E",
+	}
+	for k, v := range cases {
+		if got := humanSize(k); got != v {
+			t.Errorf("expect: %s, got: %s", v, got)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	progressInterval = 200 * time.Millisecond
+	progressBarWidth = 20
+)
+
+// progress show the aggregated progress of transfers on a terminal
+type progress struct {
+	total     int64 // total bytes, unknown if < 0
+	files     int64 // total files, unknown if < 0
+	done      int64 // transferred bytes, updated atomically
+	doneFiles int64 // transferred files, updated atomically
+	start     time.Time
+	w         io.Writer
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// isTerminal return true if f is a terminal(character device)
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// startProgress show the progress of total bytes in files(unknown if < 0) on stderr, return false if
+// nothing to transfer, a progress is already shown, or with --quiet, --presign or stderr is not a terminal
+func (sc *S3Cli) startProgress(total, files int64) bool {
+	if files == 0 || sc.progress != nil || sc.quiet || sc.presign || !isTerminal(os.Stderr) {
+		return false
+	}
+	sc.progress = newProgress(os.Stderr, total, files)
+	return true
+}
+
+// stopProgress stop the progress display and print the summary line
+func (sc *S3Cli) stopProgress() {
+	if sc.progress == nil {
+		return
+	}
+	sc.progress.finish()
+	sc.progress = nil
+}
+
+// newProgress create a progress and render it to w periodically
+func newProgress(w io.Writer, total, files int64) *progress {
+	p := &progress{
+		total: total,
+		files: files,
+		start: time.Now(),
+		w:     w,
+		stop:  make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.render()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// add n transferred bytes, negative n uncount re-read bytes
+func (p *progress) add(n int64) {
+	if p != nil {
+		atomic.AddInt64(&p.done, n)
+	}
+}
+
+// fileDone count a transferred file
+func (p *progress) fileDone() {
+	if p != nil {
+		atomic.AddInt64(&p.doneFiles, 1)
+	}
+}
+
+// rate return the average bytes per second since start
+func (p *progress) rate(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&p.done)) / elapsed.Seconds()
+}
+
+// line format the progress line: bar, bytes, percentage, throughput, ETA and files
+func (p *progress) line() string {
+	done := atomic.LoadInt64(&p.done)
+	elapsed := time.Since(p.start)
+	rate := p.rate(elapsed)
+	var b strings.Builder
+	if p.total > 0 {
+		pct := done * 100 / p.total
+		if pct > 100 {
+			pct = 100
+		}
+		n := int(pct) * progressBarWidth / 100
+		fmt.Fprintf(&b, "[%s%s] %s/%s %3d%%", strings.Repeat("=", n), strings.Repeat(" ", progressBarWidth-n), humanSize(done), humanSize(p.total), pct)
+	} else {
+		fmt.Fprintf(&b, "%s", humanSize(done))
+	}
+	fmt.Fprintf(&b, " %s/s", humanSize(int64(rate)))
+	if p.total > 0 && rate > 0 && done < p.total {
+		eta := time.Duration(float64(p.total-done) / rate * float64(time.Second))
+		fmt.Fprintf(&b, " ETA %s", eta.Round(time.Second))
+	}
+	if p.files > 1 {
+		fmt.Fprintf(&b, " %d/%d files", atomic.LoadInt64(&p.doneFiles), p.files)
+	}
+	return b.String()
+}
+
+// render redraw the progress line
+func (p *progress) render() {
+	fmt.Fprintf(p.w, "\r%s\x1b[K", p.line())
+}
+
+// summary format the final line: files, bytes, elapsed time and average throughput
+func (p *progress) summary() string {
+	elapsed := time.Since(p.start)
+	return fmt.Sprintf("%d file(s), %s in %s, %.2f MB/s", atomic.LoadInt64(&p.doneFiles), humanSize(atomic.LoadInt64(&p.done)),
+		elapsed.Round(time.Millisecond), p.rate(elapsed)/(1<<20))
+}
+
+// finish stop rendering, draw the last progress line and print the summary line
+func (p *progress) finish() {
+	close(p.stop)
+	p.wg.Wait()
+	p.render()
+	fmt.Fprintf(p.w, "\n%s\n", p.summary())
+}
+
+// progressReader count bytes read from a Object body
+type progressReader struct {
+	io.ReadCloser
+	p *progress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.p.add(int64(n))
+	return n, err
+}
+
+// progressReadSeeker count bytes read from a request body, bytes read before a seek(re-read
+// by checksum or SDK) are uncounted
+type progressReadSeeker struct {
+	io.ReadSeeker
+	p *progress
+	n int64
+}
+
+func (r *progressReadSeeker) Read(b []byte) (int, error) {
+	n, err := r.ReadSeeker.Read(b)
+	r.n += int64(n)
+	r.p.add(int64(n))
+	return n, err
+}
+
+func (r *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	r.p.add(-r.n)
+	r.n = 0
+	return r.ReadSeeker.Seek(offset, whence)
+}
+
+// progressWriter count bytes written to w
+type progressWriter struct {
+	w io.Writer
+	p *progress
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.p.add(int64(n))
+	return n, err
+}
+
+// transferBody wrap a request body to count uploaded bytes if a progress is shown,
+// and throttle it with --limit-rate
+func (sc *S3Cli) transferBody(body io.ReadSeeker) io.ReadSeeker {
+	if body == nil {
+		return body
+	}
+	if sc.progress != nil {
+		body = &progressReadSeeker{ReadSeeker: body, p: sc.progress}
+	}
+	if sc.limiter != nil {
+		body = newLimitReadSeeker(body, sc.limiter)
+	}
+	return body
+}
+
+// transferReader wrap a Object body to count downloaded bytes if a progress is shown,
+// and throttle it with --limit-rate
+func (sc *S3Cli) transferReader(body io.ReadCloser) io.ReadCloser {
+	if sc.progress != nil {
+		body = &progressReader{ReadCloser: body, p: sc.progress}
+	}
+	if sc.limiter != nil {
+		body = &limitReader{ReadCloser: body, l: sc.limiter}
+	}
+	return body
+}
+
+// transferBytes return the total size of transfers not skipped or failed
+func transferBytes(results []transferResult) (size, files int64) {
+	for _, r := range results {
+		if !r.skipped && r.err == nil {
+			size += r.size
+			files++
+		}
+	}
+	return size, files
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_progressLine(t *testing.T) {
+	p := &progress{total: 100, files: 2, done: 50, doneFiles: 1, start: time.Now().Add(-time.Second)}
+	line := p.line()
+	for _, v := range []string{"[==========          ]", "50/100", " 50%", "ETA", "1/2 files"} {
+		if !strings.Contains(line, v) {
+			t.Errorf("expect %q in progress line: %s", v, line)
+		}
+	}
+
+	p = &progress{total: -1, files: 1, done: 2048, start: time.Now().Add(-time.Second)}
+	if line := p.line(); strings.Contains(line, "%") || strings.Contains(line, "ETA") || !strings.HasPrefix(line, "2.0K") {
+		t.Errorf("unexpected progress line of unknown size: %s", line)
+	}
+}
+
+func Test_progressFinish(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgress(&buf, 10, 1)
+	p.add(10)
+	p.fileDone()
+	p.finish()
+	if out := buf.String(); !strings.Contains(out, "100%") || !strings.Contains(out, "\n1 file(s), 10 in ") || !strings.HasSuffix(out, " MB/s\n") {
+		t.Errorf("unexpected progress output: %q", out)
+	}
+}
+
+func Test_progressReadSeeker(t *testing.T) {
+	p := &progress{}
+	r := &progressReadSeeker{ReadSeeker: bytes.NewReader(testObjectContent), p: p}
+	if err := hashReadSeeker(r); err != nil {
+		t.Fatalf("hashReadSeeker failed: %s", err)
+	}
+	if p.done != 0 {
+		t.Errorf("expect re-read bytes uncounted, got: %d", p.done)
+	}
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Fatalf("read failed: %s", err)
+	}
+	if p.done != int64(len(testObjectContent)) {
+		t.Errorf("expect %d bytes, got: %d", len(testObjectContent), p.done)
+	}
+}
+
+func Test_progressTransfer(t *testing.T) {
+	key := "testProgressTransfer"
+	data := make([]byte, 2*minPartSize+1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("progressTransfer rand failed: %s", err)
+	}
+	filename := filepath.Join(t.TempDir(), "file")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatalf("progressTransfer WriteFile failed: %s", err)
+	}
+
+	sc := s3cliTest
+	sc.partSize = minPartSize
+	sc.threshold = minPartSize
+	sc.progress = newProgress(ioutil.Discard, 2*int64(len(data)), 2)
+	defer sc.stopProgress()
+	if err := sc.putFile(testBucketName, key, filename); err != nil {
+		t.Fatalf("putFile failed: %s", err)
+	}
+	if err := sc.getFile(testBucketName, key, "", filename+".out"); err != nil {
+		t.Fatalf("getFile failed: %s", err)
+	}
+	if sc.progress.done != 2*int64(len(data)) || sc.progress.doneFiles != 2 {
+		t.Errorf("expect %d bytes in 2 files, got %d bytes in %d files", 2*len(data), sc.progress.done, sc.progress.doneFiles)
+	}
+}
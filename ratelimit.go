@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// rateLimitChunk max bytes of one limited read, so a large read does not take the whole burst
+const rateLimitChunk = 32 << 10
+
+// byteLimiter block until n more bytes may be transferred
+type byteLimiter interface {
+	wait(n int)
+}
+
+// rateLimiter a token bucket of bytes shared by all concurrent transfers
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  float64 // max tokens
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter create a rateLimiter of rate bytes per second, with one second of burst
+func newRateLimiter(rate int64) *rateLimiter {
+	burst := float64(rate)
+	if burst < rateLimitChunk {
+		burst = rateLimitChunk
+	}
+	return &rateLimiter{
+		rate:   float64(rate),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// reserve take n tokens and return how long to wait before using them,
+// the bucket goes into debt so concurrent callers queue up fairly
+func (l *rateLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait block until n bytes are allowed
+func (l *rateLimiter) wait(n int) {
+	if d := l.reserve(n); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// limitReader throttle reads from a Object body
+type limitReader struct {
+	io.ReadCloser
+	l byteLimiter
+}
+
+func (r *limitReader) Read(p []byte) (int, error) {
+	if len(p) > rateLimitChunk {
+		p = p[:rateLimitChunk]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.l.wait(n)
+	return n, err
+}
+
+// limitReadSeeker throttle reads from a request body, only bytes past the high-water mark
+// are charged, so bytes re-read after a seek(SDK signing or retry) are not throttled twice
+type limitReadSeeker struct {
+	io.ReadSeeker
+	l   byteLimiter
+	pos int64 // current offset
+	max int64 // high-water mark of read offsets
+}
+
+func (r *limitReadSeeker) Read(p []byte) (int, error) {
+	if len(p) > rateLimitChunk {
+		p = p[:rateLimitChunk]
+	}
+	n, err := r.ReadSeeker.Read(p)
+	r.pos += int64(n)
+	if r.pos > r.max {
+		r.l.wait(int(r.pos - r.max))
+		r.max = r.pos
+	}
+	return n, err
+}
+
+func (r *limitReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.ReadSeeker.Seek(offset, whence)
+	if err == nil {
+		r.pos = pos
+	}
+	return pos, err
+}
+
+// newLimitReadSeeker throttle body from its current offset
+func newLimitReadSeeker(body io.ReadSeeker, l byteLimiter) *limitReadSeeker {
+	pos, _ := body.Seek(0, io.SeekCurrent)
+	return &limitReadSeeker{ReadSeeker: body, l: l, pos: pos, max: pos}
+}
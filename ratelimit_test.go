@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_rateLimiterReserve(t *testing.T) {
+	l := newRateLimiter(1 << 20)
+	if d := l.reserve(1 << 20); d != 0 {
+		t.Errorf("expect no wait within burst, got: %s", d)
+	}
+	if d := l.reserve(512 << 10); d <= 0 || d > 500*time.Millisecond {
+		t.Errorf("expect at most 500ms wait, got: %s", d)
+	}
+
+	if l := newRateLimiter(1); l.burst != rateLimitChunk {
+		t.Errorf("expect burst %d, got: %f", rateLimitChunk, l.burst)
+	}
+}
+
+// countLimiter record the bytes charged instead of waiting
+type countLimiter struct {
+	mu sync.Mutex
+	n  int64
+}
+
+func (l *countLimiter) wait(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.n += int64(n)
+}
+
+func (l *countLimiter) charged() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.n
+}
+
+func Test_limitReader(t *testing.T) {
+	l := &countLimiter{}
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &limitReader{ReadCloser: ioutil.NopCloser(bytes.NewReader(make([]byte, 768<<10))), l: l}
+			if _, err := io.Copy(ioutil.Discard, r); err != nil {
+				t.Errorf("limitReader read failed: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := l.charged(); n != 3<<19 {
+		t.Errorf("expect %d bytes charged, got: %d", 3<<19, n)
+	}
+}
+
+func Test_limitReadSeeker(t *testing.T) {
+	data := make([]byte, 100<<10)
+	body := bytes.NewReader(data)
+	body.Seek(10<<10, io.SeekStart)
+	l := &countLimiter{}
+	r := newLimitReadSeeker(body, l)
+	// read to the end, then seek back(like the SDK signer) and read again
+	for _, offset := range []int64{-1, 10 << 10, 50 << 10} {
+		if offset >= 0 {
+			if _, err := r.Seek(offset, io.SeekStart); err != nil {
+				t.Fatalf("limitReadSeeker Seek failed: %s", err)
+			}
+		}
+		if _, err := io.Copy(ioutil.Discard, r); err != nil {
+			t.Fatalf("limitReadSeeker read failed: %s", err)
+		}
+	}
+	if n := l.charged(); n != 90<<10 {
+		t.Errorf("expect %d bytes past the start offset charged once, got: %d", 90<<10, n)
+	}
+}
+
+func Test_limitRate(t *testing.T) {
+	l := newRateLimiter(1 << 20)
+	r := &limitReader{ReadCloser: ioutil.NopCloser(bytes.NewReader(make([]byte, 3<<19))), l: l}
+	start := time.Now()
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Fatalf("limitReader read failed: %s", err)
+	}
+	// 1.5M takes about 0.5s after the 1M burst
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expect at least 400ms, got: %s", elapsed)
+	}
+}
+
+func Test_limitRatePut(t *testing.T) {
+	l := &countLimiter{}
+	sc := s3cliTest
+	sc.limiter = l
+	data := make([]byte, 3<<19)
+	// the SDK reads the body to sign it and again to send it, only charged once
+	if err := sc.putObject(testBucketName, "testLimitRatePut", bytes.NewReader(data)); err != nil {
+		t.Fatalf("putObject with limit rate failed: %s", err)
+	}
+	if n := l.charged(); n != int64(len(data)) {
+		t.Errorf("putObject expect %d bytes charged, got: %d", len(data), n)
+	}
+	r, err := sc.getObject(testBucketName, "testLimitRatePut", "", "")
+	if err != nil {
+		t.Fatalf("getObject with limit rate failed: %s", err)
+	}
+	defer r.Close()
+	if got, err := ioutil.ReadAll(r); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("getObject with limit rate got: %d bytes, %v", len(got), err)
+	}
+	if n := l.charged(); n != 2*int64(len(data)) {
+		t.Errorf("getObject expect %d bytes charged, got: %d", len(data), n-int64(len(data)))
+	}
+}
@@ -1,36 +1,97 @@
 package main
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
 // S3Cli represent a S3Cli Client
 type S3Cli struct {
-	profile    string // profile in credentials file
-	endpoint   string // Server endpoine(URL)
-	ak         string // access-key
-	sk         string // secret-key
-	region     string
-	presign    bool // just presign
-	presignExp time.Duration
-	verbose    bool
-	debug      bool
-	Client     *s3.S3 // manual init this field
+	profile     string // profile in credentials file
+	endpoint    string // Server endpoine(URL)
+	ak          string // access-key
+	sk          string // secret-key
+	region      string
+	presign     bool // just presign
+	presignExp  time.Duration
+	verbose     bool
+	debug       bool
+	output      string // output format: text, json or yaml
+	partSize    int64  // MPU part size
+	threshold   int64  // upload with MPU if file size >= threshold
+	concurrency int    // parallel parts of one MPU
+	resume      bool   // resume MPU with local checkpoint file
+	noVerify    bool   // skip upload checksums and download verification
+	checksum    string // additional checksum algorithm(CRC32C, SHA256) of PutObject
+	quiet       bool   // do not show progress
+	progress    *progress
+	limiter     byteLimiter // shared bandwidth limit of all transfers
+	tagging     string      // URL encoded tags(x-amz-tagging) of uploaded Objects
+	sse         sseOptions  // server-side encryption of Object requests
+	Client      *s3.S3      // manual init this field
+}
+
+const (
+	minPartSize         int64 = 5 << 20 // 5 MiB, the last part can be smaller
+	maxPartNum          int64 = 10000
+	maxDeleteKeys             = 1000 // max keys of one DeleteObjects request
+	defaultPartSize     int64 = 16 << 20
+	defaultThreshold    int64 = 64 << 20
+	defaultConcurrency        = 4
+	putCheckpointSuffix       = ".s3cli-mpu"
+	getCheckpointSuffix       = ".s3cli-get"
+)
+
+// mpuPartSize return the part size to upload(download) size bytes
+func (sc *S3Cli) mpuPartSize(size int64) int64 {
+	partSize := sc.partSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	if partSize < minPartSize {
+		partSize = minPartSize
+	}
+	for size/partSize >= maxPartNum {
+		partSize *= 2
+	}
+	return partSize
+}
+
+// mpuThreshold return the file size to switch to MPU
+func (sc *S3Cli) mpuThreshold() int64 {
+	if sc.threshold <= 0 {
+		return defaultThreshold
+	}
+	return sc.threshold
+}
+
+// mpuConcurrency return the number of parallel parts of one MPU
+func (sc *S3Cli) mpuConcurrency() int {
+	if sc.concurrency <= 0 {
+		return defaultConcurrency
+	}
+	return sc.concurrency
 }
 
 // presignV2 presigne URL with escaped key(Object name).
@@ -122,7 +183,7 @@ func (sc *S3Cli) bucketCreate(buckets []string) error {
 		if err != nil {
 			return err
 		}
-		if sc.verbose {
+		if sc.verbose && sc.textOutput() {
 			fmt.Println(resp)
 		}
 	}
@@ -145,14 +206,22 @@ func (sc *S3Cli) bucketList() error {
 	if err != nil {
 		return err
 	}
-	if sc.verbose {
-		fmt.Println(resp)
-		return nil
-	}
+	out := bucketListOutput{Owner: ownerName(resp.Owner), Buckets: []bucketOutput{}}
 	for _, b := range resp.Buckets {
-		fmt.Println(*b.Name)
+		out.Buckets = append(out.Buckets, bucketOutput{
+			Name:         aws.StringValue(b.Name),
+			CreationDate: aws.TimeValue(b.CreationDate),
+		})
 	}
-	return nil
+	return sc.printOutput(out, func() {
+		if sc.verbose {
+			fmt.Println(resp)
+			return
+		}
+		for _, b := range resp.Buckets {
+			fmt.Println(*b.Name)
+		}
+	})
 }
 
 // bucketHead head a Bucket
@@ -173,10 +242,11 @@ func (sc *S3Cli) bucketHead(bucket string) error {
 	if err != nil {
 		return err
 	}
-	if resp != nil {
-		fmt.Println(resp)
-	}
-	return err
+	return sc.printOutput(bucketHeadOutput{Bucket: bucket}, func() {
+		if resp != nil {
+			fmt.Println(resp)
+		}
+	})
 }
 
 // bucketACLGet get a Bucket's ACL
@@ -197,10 +267,9 @@ func (sc *S3Cli) bucketACLGet(bucket string) error {
 	if err != nil {
 		return err
 	}
-	if resp != nil {
+	return sc.printOutput(newACLOutput(bucket, "", resp.Owner, resp.Grants), func() {
 		fmt.Println(resp)
-	}
-	return err
+	})
 }
 
 // bucketACLSet set a Bucket's ACL
@@ -222,7 +291,7 @@ func (sc *S3Cli) bucketACLSet(bucket string, acl string) error {
 	if err != nil {
 		return err
 	}
-	if resp != nil {
+	if resp != nil && sc.textOutput() {
 		fmt.Println(resp)
 	}
 	return err
@@ -246,8 +315,9 @@ func (sc *S3Cli) bucketPolicyGet(bucket string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(*resp.Policy)
-	return nil
+	return sc.printOutput(policyOutput{Bucket: bucket, Policy: aws.StringValue(resp.Policy)}, func() {
+		fmt.Println(*resp.Policy)
+	})
 }
 
 // bucketPolicySet set a Bucket's Policy
@@ -273,7 +343,158 @@ func (sc *S3Cli) bucketPolicySet(bucket, policy string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(*resp)
+	if sc.textOutput() {
+		fmt.Println(*resp)
+	}
+	return nil
+}
+
+// bucketWebsiteGet get a Bucket's website configuration
+func (sc *S3Cli) bucketWebsiteGet(bucket string) error {
+	req, resp := sc.Client.GetBucketWebsiteRequest(&s3.GetBucketWebsiteInput{
+		Bucket: aws.String(bucket),
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	out := websiteOutput{Bucket: bucket, RoutingRules: []routingRule{}}
+	if err := req.Send(); err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != errNoSuchWebsite {
+			return fmt.Errorf("get bucket website failed: %w", err)
+		}
+	} else {
+		if resp.IndexDocument != nil {
+			out.IndexDocument = aws.StringValue(resp.IndexDocument.Suffix)
+		}
+		if resp.ErrorDocument != nil {
+			out.ErrorDocument = aws.StringValue(resp.ErrorDocument.Key)
+		}
+		if r := resp.RedirectAllRequestsTo; r != nil {
+			out.RedirectAllRequestsTo = aws.StringValue(r.HostName)
+			if r.Protocol != nil {
+				out.RedirectAllRequestsTo = aws.StringValue(r.Protocol) + "://" + out.RedirectAllRequestsTo
+			}
+		}
+		for _, rule := range resp.RoutingRules {
+			out.RoutingRules = append(out.RoutingRules, newRoutingRule(rule))
+		}
+	}
+	return sc.printOutput(out, func() {
+		if out.IndexDocument == "" && out.RedirectAllRequestsTo == "" {
+			fmt.Printf("no website configuration of %s\n", bucket)
+			return
+		}
+		if out.RedirectAllRequestsTo != "" {
+			fmt.Printf("redirect all requests to: %s\n", out.RedirectAllRequestsTo)
+		}
+		if out.IndexDocument != "" {
+			fmt.Printf("index document: %s\n", out.IndexDocument)
+		}
+		if out.ErrorDocument != "" {
+			fmt.Printf("error document: %s\n", out.ErrorDocument)
+		}
+		for _, r := range out.RoutingRules {
+			fmt.Printf("routing rule: %s\n", r)
+		}
+	})
+}
+
+// bucketWebsiteSet set a Bucket's website configuration, and the public-read policy if public
+func (sc *S3Cli) bucketWebsiteSet(bucket, index, errorDocument string, rules []routingRule, public bool) error {
+	if err := validWebsite(index, rules); err != nil {
+		return err
+	}
+	if sc.presign && public {
+		return errors.New("--presign is not supported by --public, the Bucket policy is merged")
+	}
+	cfg := &s3.WebsiteConfiguration{
+		IndexDocument: &s3.IndexDocument{Suffix: aws.String(index)},
+	}
+	if errorDocument != "" {
+		cfg.ErrorDocument = &s3.ErrorDocument{Key: aws.String(errorDocument)}
+	}
+	for _, r := range rules {
+		cfg.RoutingRules = append(cfg.RoutingRules, r.toS3())
+	}
+	req, resp := sc.Client.PutBucketWebsiteRequest(&s3.PutBucketWebsiteInput{
+		Bucket:               aws.String(bucket),
+		WebsiteConfiguration: cfg,
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("put bucket website failed: %w", err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
+	if public {
+		if err := sc.bucketPolicyPublicRead(bucket); err != nil {
+			return fmt.Errorf("put public-read policy failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// bucketPolicyPublicRead add the public-read statement to the Bucket policy, other statements are kept
+func (sc *S3Cli) bucketPolicyPublicRead(bucket string) error {
+	policy := ""
+	resp, err := sc.Client.GetBucketPolicy(&s3.GetBucketPolicyInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != errNoSuchBucketPolicy {
+			return fmt.Errorf("get bucket policy failed: %w", err)
+		}
+	} else {
+		policy = aws.StringValue(resp.Policy)
+	}
+	policy, changed, err := mergePublicReadPolicy(policy, bucket)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		if sc.verbose && sc.textOutput() {
+			fmt.Printf("public-read policy statement %s already set\n", publicReadSid)
+		}
+		return nil
+	}
+	return sc.bucketPolicySet(bucket, policy)
+}
+
+// bucketWebsiteDelete delete a Bucket's website configuration
+func (sc *S3Cli) bucketWebsiteDelete(bucket string) error {
+	req, resp := sc.Client.DeleteBucketWebsiteRequest(&s3.DeleteBucketWebsiteInput{
+		Bucket: aws.String(bucket),
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("delete bucket website failed: %w", err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
 	return nil
 }
 
@@ -295,8 +516,14 @@ func (sc *S3Cli) bucketVersioningGet(bucket string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Printf("BucketVersioning: %s\n", resp)
-	return nil
+	out := versioningOutput{
+		Bucket:    bucket,
+		Status:    aws.StringValue(resp.Status),
+		MFADelete: aws.StringValue(resp.MFADelete),
+	}
+	return sc.printOutput(out, func() {
+		fmt.Printf("BucketVersioning: %s\n", resp)
+	})
 }
 
 // bucketVersioningSet set a Bucket's Versioning status
@@ -320,7 +547,9 @@ func (sc *S3Cli) bucketVersioningSet(bucket string, status string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Printf("BucketVersioning: %s\n", resp)
+	if sc.textOutput() {
+		fmt.Printf("BucketVersioning: %s\n", resp)
+	}
 	return nil
 }
 
@@ -345,9 +574,12 @@ func (sc *S3Cli) bucketDelete(bucket string) error {
 // putObject upload a Object
 func (sc *S3Cli) putObject(bucket, key string, r io.ReadSeeker) error {
 	putObjectInput := &s3.PutObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: sc.taggingHeader(),
 	}
+	putObjectInput.ServerSideEncryption, putObjectInput.SSEKMSKeyId = sc.sse.encryption()
+	putObjectInput.SSECustomerAlgorithm, putObjectInput.SSECustomerKey = sc.sse.customer()
 	if !reflect.ValueOf(r).IsNil() {
 		putObjectInput.Body = r
 	}
@@ -361,22 +593,141 @@ func (sc *S3Cli) putObject(bucket, key string, r io.ReadSeeker) error {
 		return err
 	}
 
+	if err := sc.setChecksum(req, putObjectInput.Body, sc.checksum); err != nil {
+		return err
+	}
+	// the body is read by Send, wrap it after the checksum is calculated
+	putObjectInput.Body = sc.transferBody(putObjectInput.Body)
 	err := req.Send()
 	if err != nil {
 		return err
 	}
-	if sc.verbose {
+	if sc.verbose && sc.textOutput() {
 		fmt.Println(resp)
 	}
 	return nil
 }
 
+// transferResult record the result of one file/Object transfer
+type transferResult struct {
+	source  string
+	dest    string
+	size    int64
+	mtime   time.Time
+	skipped bool
+	err     error
+}
+
+// parallel call fn(0..n-1) with at most jobs goroutines
+func parallel(jobs, n int, fn func(i int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	ch := make(chan int)
+	wg := sync.WaitGroup{}
+	for j := 0; j < jobs && j < n; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range ch {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		ch <- i
+	}
+	close(ch)
+	wg.Wait()
+}
+
+// printTransferSummary print per-file transfer results and return an error if any failed
+func (sc *S3Cli) printTransferSummary(results []transferResult) error {
+	out := transferSummaryOutput{Transfers: []transferOutput{}}
+	for _, r := range results {
+		t := transferOutput{Source: r.source, Dest: r.dest, Size: r.size, Status: "ok"}
+		if r.err != nil {
+			out.Failed++
+			t.Status = "failed"
+			t.Error = r.err.Error()
+		} else if r.skipped {
+			out.Skipped++
+			t.Status = "skip"
+		} else {
+			out.Transferred++
+			out.Bytes += r.size
+		}
+		out.Transfers = append(out.Transfers, t)
+	}
+	err := sc.printOutput(out, func() {
+		for _, t := range out.Transfers {
+			if t.Error != "" {
+				fmt.Printf("%s\t%s -> %s: %s\n", t.Status, t.Source, t.Dest, t.Error)
+			} else {
+				fmt.Printf("%s\t%s -> %s\n", t.Status, t.Source, t.Dest)
+			}
+		}
+		fmt.Printf("%d file(s), %d bytes transferred, %d skipped, %d failed\n", out.Transferred, out.Bytes, out.Skipped, out.Failed)
+	})
+	if err != nil {
+		return err
+	}
+	if out.Failed > 0 {
+		return fmt.Errorf("%d of %d transfer(s) failed", out.Failed, len(results))
+	}
+	return nil
+}
+
+// putObjects upload all files under local dir to bucket/prefix
+func (sc *S3Cli) putObjects(bucket, prefix, dir string, jobs int) error {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	results := []transferResult{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		results = append(results, transferResult{
+			source: path,
+			dest:   prefix + filepath.ToSlash(rel),
+			size:   info.Size(),
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk %s failed: %w", dir, err)
+	}
+
+	started := sc.startProgress(transferBytes(results))
+	parallel(jobs, len(results), func(i int) {
+		r := &results[i]
+		r.err = sc.putFile(bucket, r.dest, r.source)
+	})
+	if started {
+		sc.stopProgress()
+	}
+	if sc.presign {
+		return nil
+	}
+	return sc.printTransferSummary(results)
+}
+
 // headObject head a Object
 func (sc *S3Cli) headObject(bucket, key string, mtime, mtimestamp bool) error {
-	req, resp := sc.Client.HeadObjectRequest(&s3.HeadObjectInput{
+	input := &s3.HeadObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
-	})
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	req, resp := sc.Client.HeadObjectRequest(input)
 
 	if sc.presign {
 		s, err := req.Presign(sc.presignExp)
@@ -394,16 +745,28 @@ func (sc *S3Cli) headObject(bucket, key string, mtime, mtimestamp bool) error {
 	if resp == nil {
 		return nil
 	}
-	if sc.verbose {
-		fmt.Println(resp)
-	} else if mtime {
-		fmt.Println(resp.LastModified)
-	} else if mtimestamp {
-		fmt.Println(resp.LastModified.Unix())
-	} else {
-		fmt.Printf("%d\t%s\n", *resp.ContentLength, resp.LastModified)
-	}
-	return nil
+	out := headObjectOutput{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         aws.Int64Value(resp.ContentLength),
+		LastModified: aws.TimeValue(resp.LastModified),
+		ETag:         trimETag(resp.ETag),
+		ContentType:  aws.StringValue(resp.ContentType),
+		VersionID:    aws.StringValue(resp.VersionId),
+		StorageClass: aws.StringValue(resp.StorageClass),
+		Metadata:     aws.StringValueMap(resp.Metadata),
+	}
+	return sc.printOutput(out, func() {
+		if sc.verbose {
+			fmt.Println(resp)
+		} else if mtime {
+			fmt.Println(resp.LastModified)
+		} else if mtimestamp {
+			fmt.Println(resp.LastModified.Unix())
+		} else {
+			fmt.Printf("%d\t%s\n", *resp.ContentLength, resp.LastModified)
+		}
+	})
 }
 
 // getObjectACL get A Object's ACL
@@ -425,10 +788,9 @@ func (sc *S3Cli) getObjectACL(bucket, key string) error {
 	if err != nil {
 		return err
 	}
-	if resp != nil {
+	return sc.printOutput(newACLOutput(bucket, key, resp.Owner, resp.Grants), func() {
 		fmt.Println(resp)
-	}
-	return nil
+	})
 }
 
 // setObjectACL set A Object's ACL
@@ -451,7 +813,7 @@ func (sc *S3Cli) setObjectACL(bucket, key string, acl string) error {
 	if err != nil {
 		return err
 	}
-	if resp != nil {
+	if resp != nil && sc.textOutput() {
 		fmt.Println(resp)
 	}
 	return nil
@@ -460,11 +822,16 @@ func (sc *S3Cli) setObjectACL(bucket, key string, acl string) error {
 // listAllObjects list all Objects in specified bucket
 func (sc *S3Cli) listAllObjects(bucket, prefix, delimiter string, index bool, startTime, endTime time.Time) error {
 	var i int64
+	out := newListOutput(bucket, prefix, delimiter, nil, nil, startTime, endTime)
 	err := sc.Client.ListObjectsPages(&s3.ListObjectsInput{
 		Bucket:    aws.String(bucket),
 		Prefix:    aws.String(prefix),
 		Delimiter: aws.String(delimiter),
 	}, func(p *s3.ListObjectsOutput, last bool) (shouldContinue bool) {
+		if !sc.textOutput() {
+			out.add(p.CommonPrefixes, p.Contents, startTime, endTime)
+			return true
+		}
 		fmt.Println("Page,", i)
 		i++
 		if sc.verbose {
@@ -493,18 +860,23 @@ func (sc *S3Cli) listAllObjects(bucket, prefix, delimiter string, index bool, st
 	if err != nil {
 		return fmt.Errorf("list all objects failed: %w", err)
 	}
-	return nil
+	return sc.printOutput(out, func() {})
 }
 
 // listAllObjectsV2 list all Objects in specified bucket
 func (sc *S3Cli) listAllObjectsV2(bucket, prefix, delimiter string, index, owner bool, startTime, endTime time.Time) error {
 	var i int64
+	out := newListOutput(bucket, prefix, delimiter, nil, nil, startTime, endTime)
 	err := sc.Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
 		Bucket:     aws.String(bucket),
 		Prefix:     aws.String(prefix),
 		Delimiter:  aws.String(delimiter),
 		FetchOwner: aws.Bool(owner),
 	}, func(p *s3.ListObjectsV2Output, last bool) (shouldContinue bool) {
+		if !sc.textOutput() {
+			out.add(p.CommonPrefixes, p.Contents, startTime, endTime)
+			return true
+		}
 		fmt.Println("Page,", i)
 		i++
 		if sc.verbose {
@@ -533,7 +905,7 @@ func (sc *S3Cli) listAllObjectsV2(bucket, prefix, delimiter string, index, owner
 	if err != nil {
 		return fmt.Errorf("list all objects failed: %w", err)
 	}
-	return nil
+	return sc.printOutput(out, func() {})
 }
 
 // listObjects (S3 listBucket)list Objects in specified bucket
@@ -558,6 +930,12 @@ func (sc *S3Cli) listObjects(bucket, prefix, delimiter, marker string, maxkeys i
 	if err != nil {
 		return fmt.Errorf("list objects failed: %w", err)
 	}
+	if !sc.textOutput() {
+		out := newListOutput(bucket, prefix, delimiter, resp.CommonPrefixes, resp.Contents, startTime, endTime)
+		out.IsTruncated = aws.BoolValue(resp.IsTruncated)
+		out.NextMarker = aws.StringValue(resp.NextMarker)
+		return sc.printOutput(out, nil)
+	}
 	for _, p := range resp.CommonPrefixes {
 		fmt.Println(*p.Prefix)
 	}
@@ -602,6 +980,12 @@ func (sc *S3Cli) listObjectsV2(bucket, prefix, delimiter, marker string, maxkeys
 	if err != nil {
 		return fmt.Errorf("list objects failed: %w", err)
 	}
+	if !sc.textOutput() {
+		out := newListOutput(bucket, prefix, delimiter, resp.CommonPrefixes, resp.Contents, startTime, endTime)
+		out.IsTruncated = aws.BoolValue(resp.IsTruncated)
+		out.NextMarker = aws.StringValue(resp.NextContinuationToken)
+		return sc.printOutput(out, nil)
+	}
 	for _, p := range resp.CommonPrefixes {
 		fmt.Println(*p.Prefix)
 	}
@@ -623,6 +1007,56 @@ func (sc *S3Cli) listObjectsV2(bucket, prefix, delimiter, marker string, maxkeys
 	return nil
 }
 
+// listObjectsLong list Objects(with owner) in long format, like ls -l
+func (sc *S3Cli) listObjectsLong(bucket, prefix, delimiter, marker string, maxkeys int64, all, human bool, startTime, endTime time.Time) error {
+	input := &s3.ListObjectsV2Input{
+		Bucket:     aws.String(bucket),
+		Prefix:     aws.String(prefix),
+		Delimiter:  aws.String(delimiter),
+		FetchOwner: aws.Bool(true),
+	}
+	if marker != "" {
+		input.StartAfter = aws.String(marker)
+	}
+	if !all {
+		input.MaxKeys = aws.Int64(maxkeys)
+	}
+	if sc.presign {
+		req, _ := sc.Client.ListObjectsV2Request(input)
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	out := newListOutput(bucket, prefix, delimiter, nil, nil, startTime, endTime)
+	err := sc.Client.ListObjectsV2Pages(input, func(p *s3.ListObjectsV2Output, last bool) bool {
+		out.add(p.CommonPrefixes, p.Contents, startTime, endTime)
+		out.IsTruncated = aws.BoolValue(p.IsTruncated)
+		out.NextMarker = aws.StringValue(p.NextContinuationToken)
+		return all
+	})
+	if err != nil {
+		return fmt.Errorf("list objects failed: %w", err)
+	}
+	return sc.printOutput(out, func() {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, p := range out.CommonPrefixes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "", "DIR", "", "", "", p)
+		}
+		for _, obj := range out.Objects {
+			size := strconv.FormatInt(obj.Size, 10)
+			if human {
+				size = humanSize(obj.Size)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", obj.LastModified.UTC().Format("2006-01-02 15:04:05"),
+				size, obj.StorageClass, obj.ETag, obj.Owner, obj.Key)
+		}
+		w.Flush()
+	})
+}
+
 // listObjectVersions list Objects versions in Bucket
 func (sc *S3Cli) listObjectVersions(bucket, prefix string) error {
 	lovi := &s3.ListObjectVersionsInput{
@@ -649,8 +1083,34 @@ func (sc *S3Cli) listObjectVersions(bucket, prefix string) error {
 		return nil
 	}
 
-	fmt.Println(resp)
-	return nil
+	out := listVersionsOutput{
+		Bucket:      bucket,
+		Prefix:      prefix,
+		Versions:    []versionOutput{},
+		IsTruncated: aws.BoolValue(resp.IsTruncated),
+	}
+	for _, v := range resp.Versions {
+		out.Versions = append(out.Versions, versionOutput{
+			Key:          aws.StringValue(v.Key),
+			VersionID:    aws.StringValue(v.VersionId),
+			IsLatest:     aws.BoolValue(v.IsLatest),
+			Size:         aws.Int64Value(v.Size),
+			LastModified: aws.TimeValue(v.LastModified),
+			ETag:         trimETag(v.ETag),
+		})
+	}
+	for _, v := range resp.DeleteMarkers {
+		out.Versions = append(out.Versions, versionOutput{
+			Key:          aws.StringValue(v.Key),
+			VersionID:    aws.StringValue(v.VersionId),
+			IsLatest:     aws.BoolValue(v.IsLatest),
+			DeleteMarker: true,
+			LastModified: aws.TimeValue(v.LastModified),
+		})
+	}
+	return sc.printOutput(out, func() {
+		fmt.Println(resp)
+	})
 }
 
 // getObject download a Object from bucket
@@ -663,12 +1123,14 @@ func (sc *S3Cli) getObject(bucket, key, oRange, version string) (io.ReadCloser,
 	if version != "" {
 		versionID = aws.String(version)
 	}
-	req, resp := sc.Client.GetObjectRequest(&s3.GetObjectInput{
+	input := &s3.GetObjectInput{
 		Bucket:    aws.String(bucket),
 		Key:       aws.String(key),
 		VersionId: versionID,
 		Range:     objRange,
-	})
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	req, resp := sc.Client.GetObjectRequest(input)
 
 	if sc.presign {
 		s, err := req.Presign(sc.presignExp)
@@ -682,56 +1144,145 @@ func (sc *S3Cli) getObject(bucket, key, oRange, version string) (io.ReadCloser,
 	if err != nil {
 		return nil, fmt.Errorf("get object failed: %w", err)
 	}
-	return resp.Body, nil
+	body := resp.Body
+	if oRange == "" {
+		body = sc.verifyBody(bucket, key, resp)
+	}
+	return sc.transferReader(body), nil
 
 }
 
-// catObject print Object contents
-func (sc *S3Cli) catObject(bucket, key, oRange, version string) error {
-	var objRange *string
-	if oRange != "" {
-		objRange = aws.String(fmt.Sprintf("bytes=%s", oRange))
-	}
-	var versionID *string
-	if version != "" {
-		versionID = aws.String(version)
-	}
-	req, resp := sc.Client.GetObjectRequest(&s3.GetObjectInput{
-		Bucket:    aws.String(bucket),
-		Key:       aws.String(key),
-		VersionId: versionID,
-		Range:     objRange,
+// walkObjects list all Objects with prefix page by page and call fn for each Object
+func (sc *S3Cli) walkObjects(bucket, prefix string, fn func(obj *s3.Object) error) error {
+	var fnErr error
+	err := sc.Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(p *s3.ListObjectsV2Output, last bool) (shouldContinue bool) {
+		for _, obj := range p.Contents {
+			if fnErr = fn(obj); fnErr != nil {
+				return false
+			}
+		}
+		return true
 	})
+	if err != nil {
+		return fmt.Errorf("list all objects failed: %w", err)
+	}
+	return fnErr
+}
 
-	if sc.presign {
-		s, err := req.Presign(sc.presignExp)
-		if err == nil {
-			fmt.Println(s)
-		}
-		return err
+// localPath map key(start with prefix) to a path under local dir
+func localPath(dir, prefix, key string) (string, error) {
+	// keep the last path element of prefix, like cp -r does
+	rel := key[strings.LastIndex(prefix, "/")+1:]
+	filename := filepath.Join(dir, filepath.FromSlash(rel))
+	if r, err := filepath.Rel(dir, filename); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid key %s: outside of %s", key, dir)
 	}
+	return filename, nil
+}
 
-	err := req.Send()
+// downloadObject download a Object to local file
+func (sc *S3Cli) downloadObject(bucket, key, filename string, mtime time.Time) error {
+	r, err := sc.getObject(bucket, key, "", "")
 	if err != nil {
-		return fmt.Errorf("get object failed: %w", err)
+		return err
 	}
-	_, err = io.Copy(os.Stdout, resp.Body)
-	return err
+	if r == nil { // presign URL return nil
+		return nil
+	}
+	defer r.Close()
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	fd, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(fd, r); err != nil {
+		fd.Close()
+		os.Remove(filename)
+		return err
+	}
+	if err = fd.Close(); err != nil {
+		return err
+	}
+	sc.progress.fileDone()
+	if !mtime.IsZero() {
+		return os.Chtimes(filename, mtime, mtime)
+	}
+	return nil
 }
 
-// renameObject rename Object
-func (sc *S3Cli) renameObject(source, bucket, key string) error {
-	// TODO: Copy and Delete Object
-	return fmt.Errorf("not impl")
-}
+// getObjects download all Objects with prefix(and match the glob pattern if not empty) to local dir
+func (sc *S3Cli) getObjects(bucket, prefix, pattern, dir string, jobs int, overwrite bool) error {
+	results := []transferResult{}
+	walk := func(obj *s3.Object) error {
+		if strings.HasSuffix(*obj.Key, "/") { // directory placeholder
+			return nil
+		}
+		filename, err := localPath(dir, prefix, *obj.Key)
+		results = append(results, transferResult{
+			source: fmt.Sprintf("%s/%s", bucket, *obj.Key),
+			dest:   filename,
+			size:   aws.Int64Value(obj.Size),
+			mtime:  aws.TimeValue(obj.LastModified),
+			err:    err,
+		})
+		return nil
+	}
+	var err error
+	if pattern != "" {
+		err = sc.walkGlob(bucket, pattern, walk)
+	} else {
+		err = sc.walkObjects(bucket, prefix, walk)
+	}
+	if err != nil {
+		return err
+	}
 
-// copyObjects copy Object to destBucket/key
-func (sc *S3Cli) copyObject(source, bucket, key string) error {
-	req, resp := sc.Client.CopyObjectRequest(&s3.CopyObjectInput{
-		CopySource: aws.String(source),
-		Bucket:     aws.String(bucket),
-		Key:        aws.String(key),
+	for i := range results {
+		if _, err := os.Stat(results[i].dest); err == nil && !overwrite {
+			results[i].skipped = true
+		}
+	}
+	started := sc.startProgress(transferBytes(results))
+	parallel(jobs, len(results), func(i int) {
+		r := &results[i]
+		if r.err != nil || r.skipped {
+			return
+		}
+		_, key := splitBucketObject(r.source)
+		r.err = sc.downloadObject(bucket, key, r.dest, r.mtime)
 	})
+	if started {
+		sc.stopProgress()
+	}
+	if sc.presign {
+		return nil
+	}
+	return sc.printTransferSummary(results)
+}
+
+// catObject print Object contents, decompress gzip/zstd/bzip2 contents if decompress
+func (sc *S3Cli) catObject(bucket, key, oRange, version string, decompress bool) error {
+	var objRange *string
+	if oRange != "" {
+		objRange = aws.String(fmt.Sprintf("bytes=%s", oRange))
+	}
+	var versionID *string
+	if version != "" {
+		versionID = aws.String(version)
+	}
+	input := &s3.GetObjectInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		VersionId: versionID,
+		Range:     objRange,
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	req, resp := sc.Client.GetObjectRequest(input)
 
 	if sc.presign {
 		s, err := req.Presign(sc.presignExp)
@@ -742,14 +1293,99 @@ func (sc *S3Cli) copyObject(source, bucket, key string) error {
 	}
 
 	err := req.Send()
+	if err != nil {
+		return fmt.Errorf("get object failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body := resp.Body
+	if oRange == "" {
+		body = sc.verifyBody(bucket, key, resp)
+	}
+	var r io.Reader = sc.transferReader(body)
+	if decompress {
+		dr, err := decompressReader(r)
+		if err != nil {
+			return fmt.Errorf("decompress %s/%s failed: %w", bucket, key, err)
+		}
+		defer dr.Close()
+		r = dr
+	}
+	if _, err = io.Copy(os.Stdout, r); err != nil && decompress {
+		return fmt.Errorf("decompress %s/%s failed: %w", bucket, key, err)
+	}
+	return err
+}
+
+// copyObject copy source(bucket/key) to bucket/key, Objects not smaller than copyThreshold
+// are copied with parallel UploadPartCopy
+func (sc *S3Cli) copyObject(source, bucket, key string) error {
+	srcBucket, srcKey := splitBucketObject(source)
+	req, resp := sc.Client.CopyObjectRequest(sc.copyObjectInput(srcBucket, srcKey, bucket, key))
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	head, err := sc.headSource(srcBucket, srcKey)
+	if err != nil {
+		return err
+	}
+	if size := aws.Int64Value(head.ContentLength); size >= sc.copyThreshold() {
+		started := sc.startProgress(size, 1)
+		err := sc.copyMultipart(srcBucket, srcKey, bucket, key, head)
+		if started {
+			sc.stopProgress()
+		}
+		if err != nil {
+			return err
+		}
+		return sc.printObjectWrite(bucket, key)
+	}
+
+	err = req.Send()
 	if err != nil {
 		return fmt.Errorf("copy object failed: %w", err)
 	}
-	if sc.verbose {
-		fmt.Println(resp)
-		return nil
+	out := objectWriteOutput{
+		Bucket:    bucket,
+		Key:       key,
+		VersionID: aws.StringValue(resp.VersionId),
 	}
-	return nil
+	if resp.CopyObjectResult != nil {
+		out.ETag = trimETag(resp.CopyObjectResult.ETag)
+	}
+	return sc.printOutput(out, func() {
+		if sc.verbose {
+			fmt.Println(resp)
+		}
+	})
+}
+
+// deleteKeys delete Objects(at most 1000) in one request, and return the error of each failed key
+func (sc *S3Cli) deleteKeys(bucket string, keys []string) (map[string]error, error) {
+	objects := make([]*s3.ObjectIdentifier, 0, len(keys))
+	for _, key := range keys {
+		objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(key)})
+	}
+	resp, err := sc.Client.DeleteObjects(&s3.DeleteObjectsInput{
+		Bucket: aws.String(bucket),
+		Delete: &s3.Delete{
+			Quiet:   aws.Bool(true),
+			Objects: objects,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("delete objects failed: %w", err)
+	}
+	failed := map[string]error{}
+	for _, e := range resp.Errors {
+		failed[aws.StringValue(e.Key)] = fmt.Errorf("%s: %s", aws.StringValue(e.Code), aws.StringValue(e.Message))
+	}
+	return failed, nil
 }
 
 // deleteObjects list and delete Objects
@@ -769,7 +1405,7 @@ func (sc *S3Cli) deleteObjects(bucket, prefix string) error {
 		if objectNum == 0 {
 			break
 		}
-		if sc.verbose {
+		if sc.verbose && sc.textOutput() {
 			fmt.Printf("Got %d Objects, ", objectNum)
 		}
 		objects := make([]*s3.ObjectIdentifier, 0, 1000)
@@ -789,7 +1425,7 @@ func (sc *S3Cli) deleteObjects(bucket, prefix string) error {
 		} else {
 			objNum = objNum + int64(objectNum)
 		}
-		if sc.verbose {
+		if sc.verbose && sc.textOutput() {
 			fmt.Printf("%d Objects deleted\n", objNum)
 		}
 
@@ -838,7 +1474,7 @@ func (sc *S3Cli) deleteObject(bucket, key, version string) error {
 	if err != nil {
 		return err
 	}
-	if sc.verbose {
+	if sc.verbose && sc.textOutput() {
 		fmt.Println(resp)
 	}
 	return nil
@@ -863,40 +1499,55 @@ func (sc *S3Cli) mpuCreate(bucket, key string) error {
 		return err
 	}
 
-	fmt.Println(resp)
-	return err
+	out := uploadOutput{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: aws.StringValue(resp.UploadId),
+	}
+	return sc.printOutput(out, func() {
+		fmt.Println(resp)
+	})
 }
 
 // mpuUpload do a Multi-Part-Upload
 func (sc *S3Cli) mpuUpload(bucket, key, uid string, file map[int64]string) error {
 	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	out := listPartsOutput{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: uid,
+		Parts:    []partOutput{},
+	}
 	for i, localfile := range file {
 		wg.Add(1)
 		go func(num int64, filename string) {
 			defer wg.Done()
 			fd, err := os.Open(filename)
 			if err != nil {
-				fmt.Printf("%2d   error: %s\n", num, err)
+				fmt.Fprintf(os.Stderr, "%2d   error: %s\n", num, err)
 				return
 			}
 			defer fd.Close()
-			req, resp := sc.Client.UploadPartRequest(&s3.UploadPartInput{
-				Body:       fd,
-				Bucket:     aws.String(bucket),
-				Key:        aws.String(key),
-				PartNumber: aws.Int64(num),
-				UploadId:   aws.String(uid),
-			})
-			err = req.Send()
+			etag, err := sc.mpuUploadPart(bucket, key, uid, num, fd)
 			if err != nil {
-				fmt.Printf("%2d   error: %s\n", num, err)
+				fmt.Fprintf(os.Stderr, "%2d   error: %s\n", num, err)
+				return
+			}
+			if sc.textOutput() {
+				fmt.Printf("%2d success: %s\n", num, etag)
 				return
 			}
-			fmt.Printf("%2d success: %s\n", num, *resp.ETag)
+			mu.Lock()
+			defer mu.Unlock()
+			out.Parts = append(out.Parts, partOutput{PartNumber: num, ETag: trimETag(&etag)})
 		}(i, localfile)
 	}
 	wg.Wait()
-	return nil
+	sort.Slice(out.Parts, func(i, j int) bool {
+		return out.Parts[i].PartNumber < out.Parts[j].PartNumber
+	})
+	return sc.printOutput(out, func() {})
 }
 
 // mpuAbort abort Multi-Part-Upload
@@ -919,7 +1570,9 @@ func (sc *S3Cli) mpuAbort(bucket, key, uid string) error {
 		return err
 	}
 
-	fmt.Println(resp)
+	if sc.textOutput() {
+		fmt.Println(resp)
+	}
 	return err
 }
 
@@ -946,11 +1599,63 @@ func (sc *S3Cli) mpuList(bucket, prefix string) error {
 		return err
 	}
 
-	fmt.Println(resp)
-	return err
+	out := listUploadsOutput{
+		Bucket:  bucket,
+		Prefix:  prefix,
+		Uploads: []uploadOutput{},
+	}
+	for _, u := range resp.Uploads {
+		out.Uploads = append(out.Uploads, uploadOutput{
+			Key:       aws.StringValue(u.Key),
+			UploadID:  aws.StringValue(u.UploadId),
+			Initiated: aws.TimeValue(u.Initiated),
+		})
+	}
+	return sc.printOutput(out, func() {
+		fmt.Println(resp)
+	})
 }
 
-// mpuComplete completa Multi-Part-Upload
+// mpuParts list uploaded parts of a Multi-Part-Upload
+func (sc *S3Cli) mpuParts(bucket, key, uid string) error {
+	if sc.presign {
+		req, _ := sc.Client.ListPartsRequest(&s3.ListPartsInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			UploadId: aws.String(uid),
+		})
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	parts, err := sc.mpuListParts(bucket, key, uid)
+	if err != nil {
+		return err
+	}
+	out := listPartsOutput{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: uid,
+		Parts:    []partOutput{},
+	}
+	for _, p := range parts {
+		out.Parts = append(out.Parts, newPartOutput(p))
+	}
+	return sc.printOutput(out, func() {
+		for _, p := range parts {
+			if sc.verbose {
+				fmt.Println(p)
+			} else {
+				fmt.Printf("%d\t%s\t%d\n", aws.Int64Value(p.PartNumber), aws.StringValue(p.ETag), aws.Int64Value(p.Size))
+			}
+		}
+	})
+}
+
+// mpuComplete completa Multi-Part-Upload, the uploaded parts are listed from server if etags is empty
 func (sc *S3Cli) mpuComplete(bucket, key, uid string, etags []string) error {
 	parts := make([]*s3.CompletedPart, len(etags))
 	for i, v := range etags {
@@ -959,6 +1664,21 @@ func (sc *S3Cli) mpuComplete(bucket, key, uid string, etags []string) error {
 			ETag:       aws.String(v),
 		}
 	}
+	if len(etags) == 0 && !sc.presign {
+		uploaded, err := sc.mpuListParts(bucket, key, uid)
+		if err != nil {
+			return err
+		}
+		if len(uploaded) == 0 {
+			return fmt.Errorf("no uploaded part of UploadId %s", uid)
+		}
+		for _, p := range uploaded {
+			parts = append(parts, &s3.CompletedPart{
+				PartNumber: p.PartNumber,
+				ETag:       p.ETag,
+			})
+		}
+	}
 	req, resp := sc.Client.CompleteMultipartUploadRequest(&s3.CompleteMultipartUploadInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
@@ -980,6 +1700,581 @@ func (sc *S3Cli) mpuComplete(bucket, key, uid string, etags []string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(resp)
+	out := objectWriteOutput{
+		Bucket:    bucket,
+		Key:       key,
+		ETag:      trimETag(resp.ETag),
+		VersionID: aws.StringValue(resp.VersionId),
+	}
+	return sc.printOutput(out, func() {
+		fmt.Println(resp)
+	})
+}
+
+// mpuInit create a Multi-Part-Upload with metadata and return its UploadId
+func (sc *S3Cli) mpuInit(bucket, key string, metadata map[string]*string) (string, error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		Metadata: metadata,
+		Tagging:  sc.taggingHeader(),
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = sc.sse.encryption()
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	resp, err := sc.Client.CreateMultipartUpload(input)
+	if err != nil {
+		return "", fmt.Errorf("create multipart upload failed: %w", err)
+	}
+	return aws.StringValue(resp.UploadId), nil
+}
+
+// mpuUploadPart upload a Multi-Part-Upload part with Content-MD5 and return its ETag
+func (sc *S3Cli) mpuUploadPart(bucket, key, uid string, num int64, body io.ReadSeeker) (string, error) {
+	input := &s3.UploadPartInput{
+		Body:       body,
+		Bucket:     aws.String(bucket),
+		Key:        aws.String(key),
+		PartNumber: aws.Int64(num),
+		UploadId:   aws.String(uid),
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	req, resp := sc.Client.UploadPartRequest(input)
+	if err := sc.setChecksum(req, body, ""); err != nil {
+		return "", err
+	}
+	input.Body = sc.transferBody(body)
+	if err := req.Send(); err != nil {
+		return "", err
+	}
+	return aws.StringValue(resp.ETag), nil
+}
+
+// mpuFinish complete a Multi-Part-Upload with uploaded parts
+func (sc *S3Cli) mpuFinish(bucket, key, uid string, parts []*s3.CompletedPart) error {
+	resp, err := sc.Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		MultipartUpload: &s3.CompletedMultipartUpload{
+			Parts: parts,
+		},
+		UploadId: aws.String(uid),
+	})
+	if err != nil {
+		return fmt.Errorf("complete multipart upload failed: %w", err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// mpuListParts list all uploaded parts of a Multi-Part-Upload
+func (sc *S3Cli) mpuListParts(bucket, key, uid string) ([]*s3.Part, error) {
+	parts := []*s3.Part{}
+	err := sc.Client.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uid),
+	}, func(p *s3.ListPartsOutput, last bool) bool {
+		parts = append(parts, p.Parts...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list parts failed: %w", err)
+	}
+	return parts, nil
+}
+
+// mpuResume return the UploadId and finished parts(already on server) recorded in cp
+func (sc *S3Cli) mpuResume(bucket, key string, size int64, cp *checkpoint) (string, map[int64]string) {
+	done := map[int64]string{}
+	if cp == nil || cp.UploadID == "" || cp.PartSize <= 0 {
+		return "", done
+	}
+	parts, err := sc.mpuListParts(bucket, key, cp.UploadID)
+	if err != nil {
+		return "", done
+	}
+	for _, p := range parts {
+		num := aws.Int64Value(p.PartNumber)
+		n := cp.PartSize
+		if offset := (num - 1) * cp.PartSize; offset+n > size {
+			n = size - offset
+		}
+		etag := aws.StringValue(p.ETag)
+		if aws.Int64Value(p.Size) != n {
+			continue
+		}
+		if e, ok := cp.Parts[num]; ok && e != etag {
+			continue
+		}
+		done[num] = etag
+	}
+	return cp.UploadID, done
+}
+
+// mpuDiscard abort the stale Multi-Part-Upload recorded in cp before it is reset, so its parts are not orphaned
+func (sc *S3Cli) mpuDiscard(cp *checkpoint) {
+	if cp.UploadID == "" {
+		return
+	}
+	_, err := sc.Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(cp.Bucket),
+		Key:      aws.String(cp.Key),
+		UploadId: aws.String(cp.UploadID),
+	})
+	if err != nil && sc.verbose && sc.textOutput() {
+		fmt.Printf("abort stale UploadId %s failed: %s\n", cp.UploadID, err)
+	}
+}
+
+// mpuCancel abort the Multi-Part-Upload failed with err so its parts are not orphaned, the abort error is added to err
+func (sc *S3Cli) mpuCancel(bucket, key, uid string, err error) error {
+	_, aerr := sc.Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uid),
+	})
+	if aerr != nil {
+		return fmt.Errorf("%w, abort UploadId %s failed: %s", err, uid, aerr)
+	}
+	return err
+}
+
+// putObjectMultipart upload size bytes of r as a Object with Multi-Part-Upload,
+// the upload is resumable(not aborted on failure) if cp is not nil
+func (sc *S3Cli) putObjectMultipart(bucket, key string, r io.ReaderAt, size int64, cp *checkpoint) error {
+	partSize := sc.mpuPartSize(size)
+	uid, done := sc.mpuResume(bucket, key, size, cp)
+	if uid != "" {
+		partSize = cp.PartSize
+		if sc.verbose && sc.textOutput() {
+			fmt.Printf("resume UploadId %s, %d part(s) already uploaded\n", uid, len(done))
+		}
+	} else {
+		var err error
+		if uid, err = sc.mpuInit(bucket, key, sc.partSizeMetadata(nil, partSize)); err != nil {
+			return err
+		}
+		if cp != nil {
+			sc.mpuDiscard(cp)
+			cp.reset(cp.Bucket, cp.Key, cp.Size, cp.ModTime, partSize)
+			cp.UploadID = uid
+			if err := cp.save(); err != nil {
+				return fmt.Errorf("save checkpoint failed: %w", err)
+			}
+		}
+	}
+	abort := func(err error) error {
+		if cp != nil {
+			return fmt.Errorf("%w, rerun with --resume to continue(checkpoint %s)", err, cp.path)
+		}
+		return sc.mpuCancel(bucket, key, uid, err)
+	}
+
+	partNum := int((size + partSize - 1) / partSize)
+	parts := make([]*s3.CompletedPart, partNum)
+	errs := make([]error, partNum)
+	parallel(sc.mpuConcurrency(), partNum, func(i int) {
+		num := int64(i + 1)
+		offset := int64(i) * partSize
+		n := partSize
+		if offset+n > size {
+			n = size - offset
+		}
+		if etag, ok := done[num]; ok {
+			sc.progress.add(n)
+			parts[i] = &s3.CompletedPart{ETag: aws.String(etag), PartNumber: aws.Int64(num)}
+			return
+		}
+		etag, err := sc.mpuUploadPart(bucket, key, uid, num, io.NewSectionReader(r, offset, n))
+		if err != nil {
+			errs[i] = fmt.Errorf("upload part %d failed: %w", num, err)
+			return
+		}
+		if cp != nil {
+			if err := cp.done(num, etag); err != nil {
+				errs[i] = fmt.Errorf("save checkpoint failed: %w", err)
+				return
+			}
+		}
+		parts[i] = &s3.CompletedPart{ETag: aws.String(etag), PartNumber: aws.Int64(num)}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return abort(err)
+		}
+	}
+	if err := sc.mpuFinish(bucket, key, uid, parts); err != nil {
+		return abort(err)
+	}
+	if cp != nil {
+		return cp.remove()
+	}
+	return nil
+}
+
+// putStdin upload stdin(r) of unknown size as bucket/key, with Multi-Part-Upload if it is longer than partSize
+func (sc *S3Cli) putStdin(bucket, key string, r io.Reader) error {
+	if key == "" || strings.HasSuffix(key, "/") {
+		return fmt.Errorf("key is required to put from stdin")
+	}
+	if sc.presign || sc.resume {
+		return fmt.Errorf("--presign and --resume are not supported to put from stdin")
+	}
+	if sc.startProgress(-1, 1) {
+		defer sc.stopProgress()
+	}
+	return sc.putReader(bucket, key, r, -1, "", nil)
+}
+
+// putReader upload size bytes(unknown if < 0) read from r as a Object, with Multi-Part-Upload if
+// size is unknown or >= threshold, at most concurrency parts are buffered in memory
+func (sc *S3Cli) putReader(bucket, key string, r io.Reader, size int64, contentType string, metadata map[string]*string) error {
+	var ct *string
+	if contentType != "" {
+		ct = aws.String(contentType)
+	}
+	put := func(data []byte) error {
+		input := &s3.PutObjectInput{
+			Body:        bytes.NewReader(data),
+			Bucket:      aws.String(bucket),
+			Key:         aws.String(key),
+			ContentType: ct,
+			Metadata:    sc.partSizeMetadata(metadata, 0),
+			Tagging:     sc.taggingHeader(),
+		}
+		input.ServerSideEncryption, input.SSEKMSKeyId = sc.sse.encryption()
+		input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+		req, _ := sc.Client.PutObjectRequest(input)
+		if err := sc.setChecksum(req, input.Body, sc.checksum); err != nil {
+			return err
+		}
+		input.Body = sc.transferBody(input.Body)
+		if err := req.Send(); err != nil {
+			return fmt.Errorf("put object failed: %w", err)
+		}
+		sc.progress.fileDone()
+		return nil
+	}
+	if size >= 0 && size < sc.mpuThreshold() {
+		data, err := ioutil.ReadAll(io.LimitReader(r, size))
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) != size {
+			return fmt.Errorf("read %d bytes, expect %d: %w", len(data), size, io.ErrUnexpectedEOF)
+		}
+		return put(data)
+	}
+
+	// read the first part before create MPU, a stream shorter than one part is put as a single Object
+	partSize := sc.mpuPartSize(size)
+	first := make([]byte, partSize)
+	n, err := io.ReadFull(r, first)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return put(first[:n])
+	}
+	if err != nil {
+		return fmt.Errorf("read part 1 failed: %w", err)
+	}
+	if err := sc.multipartChecksum(); err != nil {
+		return err
+	}
+
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: ct,
+		Metadata:    sc.partSizeMetadata(metadata, partSize),
+		Tagging:     sc.taggingHeader(),
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = sc.sse.encryption()
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	resp, err := sc.Client.CreateMultipartUpload(input)
+	if err != nil {
+		return fmt.Errorf("create multipart upload failed: %w", err)
+	}
+	uid := aws.StringValue(resp.UploadId)
+
+	concurrency := sc.mpuConcurrency()
+	buffers := make(chan []byte, concurrency)
+	for i := 1; i < concurrency; i++ {
+		buffers <- nil // allocated on first use, the first part buffer is the last one
+	}
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		parts []*s3.CompletedPart
+		errs  []error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	}
+	upload := func(num int64, buf []byte, n int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { buffers <- buf }()
+			etag, err := sc.mpuUploadPart(bucket, key, uid, num, bytes.NewReader(buf[:n]))
+			if err != nil {
+				fail(fmt.Errorf("upload part %d failed: %w", num, err))
+				return
+			}
+			mu.Lock()
+			parts = append(parts, &s3.CompletedPart{ETag: aws.String(etag), PartNumber: aws.Int64(num)})
+			mu.Unlock()
+		}()
+	}
+
+	upload(1, first, n)
+	for num := int64(2); !failed(); num++ {
+		buf := <-buffers
+		if buf == nil {
+			buf = make([]byte, partSize)
+		}
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			fail(fmt.Errorf("read part %d failed: %w", num, err))
+			break
+		}
+		if num > maxPartNum {
+			fail(fmt.Errorf("too many parts(> %d), increase --part-size", maxPartNum))
+			break
+		}
+		upload(num, buf, n)
+		if err == io.ErrUnexpectedEOF { // the last part
+			break
+		}
+	}
+	wg.Wait()
+	if len(errs) == 0 {
+		sort.Slice(parts, func(i, j int) bool {
+			return *parts[i].PartNumber < *parts[j].PartNumber
+		})
+		if err = sc.mpuFinish(bucket, key, uid, parts); err == nil {
+			sc.progress.fileDone()
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return sc.mpuCancel(bucket, key, uid, errs[0])
+}
+
+// putFile upload a local file, with Multi-Part-Upload if it is large
+func (sc *S3Cli) putFile(bucket, key, filename string) error {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	fi, err := fd.Stat()
+	if err != nil {
+		return err
+	}
+	if sc.startProgress(fi.Size(), 1) {
+		defer sc.stopProgress()
+	}
+	if !sc.presign && fi.Size() > 0 && fi.Size() >= sc.mpuThreshold() {
+		if err := sc.multipartChecksum(); err != nil {
+			return err
+		}
+		var cp *checkpoint
+		if sc.resume {
+			if cp, err = loadCheckpoint(filename + putCheckpointSuffix); err != nil {
+				return fmt.Errorf("load checkpoint failed: %w", err)
+			}
+			if !cp.match(bucket, key, fi.Size(), fi.ModTime()) {
+				sc.mpuDiscard(cp)
+				cp.reset(bucket, key, fi.Size(), fi.ModTime(), 0)
+			}
+		}
+		err = sc.putObjectMultipart(bucket, key, fd, fi.Size(), cp)
+	} else {
+		err = sc.putObject(bucket, key, fd)
+	}
+	if err == nil {
+		sc.progress.fileDone()
+	}
 	return err
 }
+
+// offsetWriter write to a io.WriterAt from offset
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.w.WriteAt(p, ow.offset)
+	ow.offset += int64(n)
+	return n, err
+}
+
+// getObjectRange download bytes [start, end] of a Object(version with etag) to w
+func (sc *S3Cli) getObjectRange(bucket, key, version, etag string, start, end int64, w io.Writer) error {
+	input := &s3.GetObjectInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Range:   aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		IfMatch: aws.String(etag),
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	resp, err := sc.Client.GetObject(input)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	n, err := io.Copy(w, sc.transferReader(resp.Body))
+	if err != nil {
+		return err
+	}
+	if n != end-start+1 {
+		return fmt.Errorf("expect %d bytes, got %d bytes", end-start+1, n)
+	}
+	return nil
+}
+
+// getFile download a Object(version) to local file, large Object is downloaded in parallel ranges
+func (sc *S3Cli) getFile(bucket, key, version, filename string) error {
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey = sc.sse.customer()
+	head, err := sc.Client.HeadObject(input)
+	if err != nil {
+		return fmt.Errorf("head object failed: %w", err)
+	}
+	size := aws.Int64Value(head.ContentLength)
+	if sc.startProgress(size, 1) {
+		defer sc.stopProgress()
+	}
+	if size < sc.mpuThreshold() {
+		r, err := sc.getObject(bucket, key, "", version)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		fd, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		if _, err = io.Copy(fd, r); err != nil {
+			fd.Close()
+			os.Remove(filename)
+			return err
+		}
+		sc.progress.fileDone()
+		return nil
+	}
+
+	etag := aws.StringValue(head.ETag)
+	mtime := aws.TimeValue(head.LastModified)
+	partSize := sc.mpuPartSize(size)
+	var cp *checkpoint
+	done := map[int64]string{}
+	if sc.resume {
+		if cp, err = loadCheckpoint(filename + getCheckpointSuffix); err != nil {
+			return fmt.Errorf("load checkpoint failed: %w", err)
+		}
+		if cp.match(bucket, key, size, mtime) && cp.ETag == etag && cp.PartSize > 0 {
+			partSize = cp.PartSize
+			// copy the parts, cp.done updates cp.Parts while the ranges are downloaded
+			cp.mu.Lock()
+			for num, etag := range cp.Parts {
+				done[num] = etag
+			}
+			cp.mu.Unlock()
+			if sc.verbose && sc.textOutput() {
+				fmt.Printf("resume download, %d part(s) already downloaded\n", len(done))
+			}
+		} else {
+			cp.reset(bucket, key, size, mtime, partSize)
+			cp.ETag = etag
+			if err := cp.save(); err != nil {
+				return fmt.Errorf("save checkpoint failed: %w", err)
+			}
+		}
+	}
+
+	fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	if err := fd.Truncate(size); err != nil {
+		return err
+	}
+	partNum := int((size + partSize - 1) / partSize)
+	errs := make([]error, partNum)
+	var written int64 // bytes of all ranges, downloaded now or before resume
+	parallel(sc.mpuConcurrency(), partNum, func(i int) {
+		num := int64(i + 1)
+		start := int64(i) * partSize
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		if _, ok := done[num]; ok {
+			atomic.AddInt64(&written, end-start+1)
+			sc.progress.add(end - start + 1)
+			return
+		}
+		w := &offsetWriter{w: fd, offset: start}
+		err := sc.getObjectRange(bucket, key, version, etag, start, end, w)
+		atomic.AddInt64(&written, w.offset-start)
+		if err != nil {
+			errs[i] = fmt.Errorf("get range %d-%d failed: %w", start, end, err)
+			return
+		}
+		if cp != nil {
+			if err := cp.done(num, ""); err != nil {
+				errs[i] = fmt.Errorf("save checkpoint failed: %w", err)
+			}
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			if cp != nil {
+				return fmt.Errorf("%w, rerun with --resume to continue(checkpoint %s)", err, cp.path)
+			}
+			fd.Close()
+			os.Remove(filename)
+			return err
+		}
+	}
+	if written != size {
+		return fmt.Errorf("download %s failed: expect %d bytes, got %d bytes", filename, size, written)
+	}
+	if err := sc.verifyFile(bucket, key, io.NewSectionReader(fd, 0, size), head); err != nil {
+		fd.Close()
+		os.Remove(filename)
+		if cp != nil {
+			cp.remove()
+		}
+		return err
+	}
+	sc.progress.fileDone()
+	if cp != nil {
+		return cp.remove()
+	}
+	return nil
+}
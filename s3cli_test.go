@@ -12,10 +12,15 @@ import (
 	mrand "math/rand"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
@@ -134,6 +139,34 @@ func Test_putObject(t *testing.T) {
 	}
 }
 
+func Test_putObjects(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{"file1", "sub/file2", "sub/subsub/file3"}
+	for _, v := range files {
+		name := filepath.Join(dir, filepath.FromSlash(v))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Errorf("putObjects MkdirAll failed: %s", err)
+			return
+		}
+		if err := ioutil.WriteFile(name, testObjectContent, 0644); err != nil {
+			t.Errorf("putObjects WriteFile failed: %s", err)
+			return
+		}
+	}
+
+	prefix := "testPutObjects"
+	if err := s3cliTest.putObjects(testBucketName, prefix, dir, 2); err != nil {
+		t.Errorf("putObjects failed: %s", err)
+		return
+	}
+	for _, v := range files {
+		key := prefix + "/" + v
+		if _, err := s3Backend.HeadObject(testBucketName, key); err != nil {
+			t.Errorf("putObjects backend HeadObject %s failed: %s", key, err)
+		}
+	}
+}
+
 func Test_headObject(t *testing.T) {
 	if err := s3cliTest.headObject(testBucketName, testObjectKey, false, false); err != nil {
 		t.Errorf("headObject failed: %s", err)
@@ -164,6 +197,12 @@ func Test_listObjects(t *testing.T) {
 	}
 }
 
+func Test_listObjectsLong(t *testing.T) {
+	if err := s3cliTest.listObjectsLong(testBucketName, "", "/", "", 1000, true, true, time.Time{}, time.Now().Add(time.Hour)); err != nil {
+		t.Errorf("listObjectsLong failed: %s", err)
+	}
+}
+
 func Test_listObjectVersions(t *testing.T) {
 	if err := s3cliTest.listObjectVersions(testBucketName, ""); err != nil {
 		t.Errorf("listObjectVersions failed: %s", err)
@@ -188,16 +227,104 @@ func Test_getObject(t *testing.T) {
 	}
 }
 
+func Test_getObjects(t *testing.T) {
+	prefix := "testGetObjects/"
+	keys := []string{"file1", "sub/file2", "sub/subsub/file3"}
+	for _, v := range keys {
+		_, err := s3Backend.PutObject(testBucketName, prefix+v, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent)))
+		if err != nil {
+			t.Errorf("getObjects backend PutObject failed: %s", err)
+			return
+		}
+	}
+
+	dir := t.TempDir()
+	if err := s3cliTest.getObjects(testBucketName, prefix, "", dir, 2, false); err != nil {
+		t.Errorf("getObjects failed: %s", err)
+		return
+	}
+	for _, v := range keys {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(v)))
+		if err != nil {
+			t.Errorf("getObjects ReadFile failed: %s", err)
+			continue
+		}
+		if !bytes.Equal(data, testObjectContent) {
+			t.Errorf("expect %s, got %s", testObjectContent, data)
+		}
+	}
+}
+
+func Test_localPath(t *testing.T) {
+	cases := map[[2]string]string{
+		{"logs/", "logs/a/b.log"}:      filepath.Join("out", "a", "b.log"),
+		{"logs/20", "logs/2024/b.log"}: filepath.Join("out", "2024", "b.log"),
+		{"", "a/b.log"}:                filepath.Join("out", "a", "b.log"),
+	}
+	for k, v := range cases {
+		filename, err := localPath("out", k[0], k[1])
+		if err != nil || filename != v {
+			t.Errorf("expect: %s, got: %s, %v", v, filename, err)
+		}
+	}
+	if _, err := localPath("out", "", "../../etc/passwd"); err == nil {
+		t.Errorf("expect error for key outside of local dir")
+	}
+}
+
 func Test_catObject(t *testing.T) {
-	if err := s3cliTest.catObject(testBucketName, testObjectKey, "", ""); err != nil {
+	if err := s3cliTest.catObject(testBucketName, testObjectKey, "", "", false); err != nil {
 		t.Errorf("catObject failed: %s", err)
 	}
 }
 
 func Test_renameObject(t *testing.T) {
-	t.Skip("not impl")
-	if err := s3cliTest.renameObject("source", testBucketName, "key"); err != nil {
+	source := "testRenameObjectSource"
+	if _, err := s3Backend.PutObject(testBucketName, source, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+		t.Errorf("renameObject backend PutObject failed: %s", err)
+		return
+	}
+	if err := s3cliTest.renameObject(testBucketName+"/"+source, testBucketName, "testRenameObjectDest"); err != nil {
 		t.Errorf("renameObject failed: %s", err)
+		return
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, "testRenameObjectDest"); err != nil {
+		t.Errorf("renameObject backend HeadObject failed: %s", err)
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, source); err == nil {
+		t.Errorf("renameObject did not delete source")
+	}
+	if err := s3cliTest.renameObject(testBucketName+"/"+testObjectKey, testBucketName, testObjectKey); err == nil {
+		t.Errorf("expect error if rename to itself")
+	}
+}
+
+func Test_moveObjects(t *testing.T) {
+	keys := []string{"file1", "sub/file2"}
+	for _, v := range keys {
+		if _, err := s3Backend.PutObject(testBucketName, "testMoveOld/"+v, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+			t.Errorf("moveObjects backend PutObject failed: %s", err)
+			return
+		}
+	}
+	if _, err := s3Backend.PutObject(testBucketName, "testMoveOlder/file1", nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+		t.Errorf("moveObjects backend PutObject failed: %s", err)
+		return
+	}
+	if err := s3cliTest.moveObjects(testBucketName, "testMoveOld", testBucketName, "testMoveNew", 2); err != nil {
+		t.Errorf("moveObjects failed: %s", err)
+		return
+	}
+	for _, v := range keys {
+		if _, err := s3Backend.HeadObject(testBucketName, "testMoveNew/"+v); err != nil {
+			t.Errorf("moveObjects did not move %s: %s", v, err)
+		}
+		if _, err := s3Backend.HeadObject(testBucketName, "testMoveOld/"+v); err == nil {
+			t.Errorf("moveObjects did not delete %s", v)
+		}
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, "testMoveOlder/file1"); err != nil {
+		t.Errorf("moveObjects moved sibling testMoveOlder/file1: %s", err)
 	}
 }
 
@@ -314,3 +441,314 @@ func presignV2Raw(method, server, bucket, key, ak, sk, contentType string, exp i
 
 	return u.String(), nil
 }
+
+func Test_putObjectMultipart(t *testing.T) {
+	key := "testPutObjectMultipart"
+	data := make([]byte, 2*minPartSize+1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Errorf("putObjectMultipart rand failed: %s", err)
+		return
+	}
+	sc := s3cliTest
+	sc.partSize = minPartSize
+	if err := sc.putObjectMultipart(testBucketName, key, bytes.NewReader(data), int64(len(data)), nil); err != nil {
+		t.Errorf("putObjectMultipart failed: %s", err)
+		return
+	}
+	obj, err := s3Backend.GetObject(testBucketName, key, nil)
+	if err != nil {
+		t.Errorf("putObjectMultipart backend GetObject failed: %s", err)
+		return
+	}
+	defer obj.Contents.Close()
+	got, err := ioutil.ReadAll(obj.Contents)
+	if err != nil {
+		t.Errorf("putObjectMultipart backend read failed: %s", err)
+		return
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("putObjectMultipart content mismatch, expect %d bytes, got %d bytes", len(data), len(got))
+	}
+}
+
+func Test_mpuPartSize(t *testing.T) {
+	sc := S3Cli{partSize: 1024}
+	if got := sc.mpuPartSize(1 << 20); got != minPartSize {
+		t.Errorf("expect: %d, got: %d", minPartSize, got)
+	}
+	if got := sc.mpuPartSize(maxPartNum * minPartSize); got != 2*minPartSize {
+		t.Errorf("expect: %d, got: %d", 2*minPartSize, got)
+	}
+}
+
+func Test_putFileResume(t *testing.T) {
+	key := "testPutFileResume"
+	data := make([]byte, 2*minPartSize+1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Errorf("putFileResume rand failed: %s", err)
+		return
+	}
+	filename := filepath.Join(t.TempDir(), "file")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Errorf("putFileResume WriteFile failed: %s", err)
+		return
+	}
+	fi, err := os.Stat(filename)
+	if err != nil {
+		t.Errorf("putFileResume Stat failed: %s", err)
+		return
+	}
+
+	// simulate an interrupted upload with part 1 uploaded
+	uid, err := s3cliTest.mpuInit(testBucketName, key, nil)
+	if err != nil {
+		t.Errorf("putFileResume mpuInit failed: %s", err)
+		return
+	}
+	etag, err := s3cliTest.mpuUploadPart(testBucketName, key, uid, 1, bytes.NewReader(data[:minPartSize]))
+	if err != nil {
+		t.Errorf("putFileResume mpuUploadPart failed: %s", err)
+		return
+	}
+	cp := &checkpoint{path: filename + putCheckpointSuffix}
+	cp.reset(testBucketName, key, fi.Size(), fi.ModTime(), minPartSize)
+	cp.UploadID = uid
+	if err := cp.done(1, etag); err != nil {
+		t.Errorf("putFileResume save checkpoint failed: %s", err)
+		return
+	}
+
+	sc := s3cliTest
+	sc.resume = true
+	sc.threshold = minPartSize
+	if err := sc.putFile(testBucketName, key, filename); err != nil {
+		t.Errorf("putFile resume failed: %s", err)
+		return
+	}
+	if _, err := os.Stat(cp.path); !os.IsNotExist(err) {
+		t.Errorf("putFile resume did not remove checkpoint %s", cp.path)
+	}
+	obj, err := s3Backend.GetObject(testBucketName, key, nil)
+	if err != nil {
+		t.Errorf("putFileResume backend GetObject failed: %s", err)
+		return
+	}
+	defer obj.Contents.Close()
+	got, err := ioutil.ReadAll(obj.Contents)
+	if err != nil {
+		t.Errorf("putFileResume backend read failed: %s", err)
+		return
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("putFileResume content mismatch, expect %d bytes, got %d bytes", len(data), len(got))
+	}
+}
+
+func Test_putFileResumeStale(t *testing.T) {
+	key := "testPutFileResumeStale"
+	data := make([]byte, minPartSize+1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Errorf("putFileResumeStale rand failed: %s", err)
+		return
+	}
+	filename := filepath.Join(t.TempDir(), "file")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Errorf("putFileResumeStale WriteFile failed: %s", err)
+		return
+	}
+
+	// checkpoint of the file before it changed
+	uid, err := s3cliTest.mpuInit(testBucketName, key, nil)
+	if err != nil {
+		t.Errorf("putFileResumeStale mpuInit failed: %s", err)
+		return
+	}
+	cp := &checkpoint{path: filename + putCheckpointSuffix}
+	cp.reset(testBucketName, key, int64(len(data))-1, time.Now().Add(-time.Hour), minPartSize)
+	cp.UploadID = uid
+	if err := cp.save(); err != nil {
+		t.Errorf("putFileResumeStale save checkpoint failed: %s", err)
+		return
+	}
+
+	sc := s3cliTest
+	sc.resume = true
+	sc.threshold = minPartSize
+	if err := sc.putFile(testBucketName, key, filename); err != nil {
+		t.Errorf("putFile resume failed: %s", err)
+		return
+	}
+	if _, err := s3cliTest.mpuListParts(testBucketName, key, uid); err == nil {
+		t.Errorf("putFile resume did not abort stale UploadId %s", uid)
+	}
+}
+
+func Test_putStdin(t *testing.T) {
+	data := make([]byte, 2*minPartSize+100)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("putStdin rand failed: %s", err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("putStdin Pipe failed: %s", err)
+	}
+	go func() {
+		w.Write(data)
+		w.Close()
+	}()
+	defer r.Close()
+
+	sc := s3cliTest
+	sc.partSize = minPartSize
+	sc.concurrency = 2
+	key := "testPutStdin"
+	if err := sc.putStdin(testBucketName, "", r); err == nil {
+		t.Errorf("expect error to put stdin without key")
+	}
+	if err := sc.putStdin(testBucketName, key, r); err != nil {
+		t.Fatalf("putStdin failed: %s", err)
+	}
+	obj, err := s3Backend.GetObject(testBucketName, key, nil)
+	if err != nil {
+		t.Fatalf("putStdin backend GetObject failed: %s", err)
+	}
+	defer obj.Contents.Close()
+	got, err := ioutil.ReadAll(obj.Contents)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("putStdin content mismatch: %d bytes, %v", len(got), err)
+	}
+}
+
+func Test_mpuParts(t *testing.T) {
+	key := "testMpuParts"
+	uid, err := s3cliTest.mpuInit(testBucketName, key, nil)
+	if err != nil {
+		t.Errorf("mpuParts mpuInit failed: %s", err)
+		return
+	}
+	if _, err := s3cliTest.mpuUploadPart(testBucketName, key, uid, 1, bytes.NewReader(testObjectContent)); err != nil {
+		t.Errorf("mpuParts mpuUploadPart failed: %s", err)
+		return
+	}
+	if err := s3cliTest.mpuParts(testBucketName, key, uid); err != nil {
+		t.Errorf("mpuParts failed: %s", err)
+	}
+}
+
+func Test_mpuCompleteListParts(t *testing.T) {
+	key := "testMpuCompleteListParts"
+	uid, err := s3cliTest.mpuInit(testBucketName, key, nil)
+	if err != nil {
+		t.Errorf("mpuComplete mpuInit failed: %s", err)
+		return
+	}
+	// part numbers with gap
+	for _, num := range []int64{2, 5} {
+		if _, err := s3cliTest.mpuUploadPart(testBucketName, key, uid, num, bytes.NewReader(testObjectContent)); err != nil {
+			t.Errorf("mpuComplete mpuUploadPart failed: %s", err)
+			return
+		}
+	}
+	if err := s3cliTest.mpuComplete(testBucketName, key, uid, nil); err != nil {
+		t.Errorf("mpuComplete failed: %s", err)
+		return
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, key); err != nil {
+		t.Errorf("mpuComplete backend HeadObject failed: %s", err)
+	}
+}
+
+func Test_getFile(t *testing.T) {
+	key := "testGetFile"
+	data := make([]byte, 2*minPartSize+1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Errorf("getFile rand failed: %s", err)
+		return
+	}
+	if _, err := s3Backend.PutObject(testBucketName, key, nil, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Errorf("getFile backend PutObject failed: %s", err)
+		return
+	}
+
+	sc := s3cliTest
+	sc.partSize = minPartSize
+	sc.threshold = minPartSize
+	sc.resume = true
+	filename := filepath.Join(t.TempDir(), "file")
+	if err := sc.getFile(testBucketName, key, "", filename); err != nil {
+		t.Errorf("getFile failed: %s", err)
+		return
+	}
+	got, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Errorf("getFile ReadFile failed: %s", err)
+		return
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("getFile content mismatch, expect %d bytes, got %d bytes", len(data), len(got))
+	}
+	if _, err := os.Stat(filename + getCheckpointSuffix); !os.IsNotExist(err) {
+		t.Errorf("getFile did not remove checkpoint")
+	}
+}
+
+func Test_getFileResume(t *testing.T) {
+	key := "testGetFileResume"
+	data := make([]byte, 7*minPartSize+1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("getFileResume rand failed: %s", err)
+	}
+	if _, err := s3Backend.PutObject(testBucketName, key, nil, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("getFileResume backend PutObject failed: %s", err)
+	}
+	head, err := s3cliTest.headKey(testBucketName, key)
+	if err != nil {
+		t.Fatalf("getFileResume headKey failed: %s", err)
+	}
+
+	// simulate an interrupted download with the last 2 parts downloaded
+	filename := filepath.Join(t.TempDir(), "file")
+	partial := make([]byte, len(data))
+	copy(partial[6*minPartSize:], data[6*minPartSize:])
+	if err := ioutil.WriteFile(filename, partial, 0644); err != nil {
+		t.Fatalf("getFileResume WriteFile failed: %s", err)
+	}
+	cp := &checkpoint{path: filename + getCheckpointSuffix}
+	cp.reset(testBucketName, key, int64(len(data)), aws.TimeValue(head.LastModified), minPartSize)
+	cp.ETag = aws.StringValue(head.ETag)
+	for _, num := range []int64{7, 8} {
+		if err := cp.done(num, ""); err != nil {
+			t.Fatalf("getFileResume save checkpoint failed: %s", err)
+		}
+	}
+
+	sc := s3cliTest
+	sc.partSize = minPartSize
+	sc.threshold = minPartSize
+	sc.concurrency = 4
+	sc.resume = true
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	var ranges int32
+	client.Handlers.Send.PushFront(func(r *request.Request) {
+		if r.Operation.Name == "GetObject" && r.HTTPRequest.Header.Get("Range") != "" {
+			atomic.AddInt32(&ranges, 1)
+		}
+	})
+	sc.Client = client
+	if err := sc.getFile(testBucketName, key, "", filename); err != nil {
+		t.Fatalf("getFile resume failed: %s", err)
+	}
+	if ranges != 6 {
+		t.Errorf("expect 6 parts downloaded on resume, got: %d", ranges)
+	}
+	got, err := ioutil.ReadFile(filename)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("getFileResume content mismatch: %d bytes, %v", len(got), err)
+	}
+	if _, err := os.Stat(cp.path); !os.IsNotExist(err) {
+		t.Errorf("getFile resume did not remove checkpoint %s", cp.path)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// sseCustomerKeySize size of a SSE-C key(AES256)
+	sseCustomerKeySize = 32
+	// errNoSuchEncryption error code of a Bucket without default encryption
+	errNoSuchEncryption = "ServerSideEncryptionConfigurationNotFoundError"
+)
+
+// sseOptions server-side encryption of Object requests
+type sseOptions struct {
+	sse         string // AES256(SSE-S3) or aws:kms(SSE-KMS)
+	kmsKeyID    string // SSE-KMS key ID
+	customerKey string // SSE-C key of the Object
+	sourceKey   string // SSE-C key of the copy source Object
+}
+
+// validSSE check and normalize the SSE algorithm, an empty one is aws:kms if kmsKeyID is set
+func validSSE(sse, kmsKeyID string) (string, error) {
+	switch {
+	case sse == "" && kmsKeyID != "":
+		return s3.ServerSideEncryptionAwsKms, nil
+	case sse == "":
+		return "", nil
+	case strings.EqualFold(sse, s3.ServerSideEncryptionAes256) && kmsKeyID == "":
+		return s3.ServerSideEncryptionAes256, nil
+	case strings.EqualFold(sse, s3.ServerSideEncryptionAwsKms):
+		return s3.ServerSideEncryptionAwsKms, nil
+	case strings.EqualFold(sse, s3.ServerSideEncryptionAes256):
+		return "", fmt.Errorf("KMS key ID requires SSE aws:kms")
+	}
+	return "", fmt.Errorf("invalid SSE: %s(AES256, aws:kms)", sse)
+}
+
+// loadSSECKey read a SSE-C key file of 32 raw bytes or its base64 encoding
+func loadSSECKey(filename string) (string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	if len(data) == sseCustomerKeySize {
+		return string(data), nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != sseCustomerKeySize {
+		return "", fmt.Errorf("invalid SSE-C key file %s: expect %d bytes or their base64 encoding", filename, sseCustomerKeySize)
+	}
+	return string(key), nil
+}
+
+// encryption return the x-amz-server-side-encryption(-aws-kms-key-id) of Object writes
+func (o sseOptions) encryption() (sse, kmsKeyID *string) {
+	if o.sse != "" {
+		sse = aws.String(o.sse)
+	}
+	if o.kmsKeyID != "" {
+		kmsKeyID = aws.String(o.kmsKeyID)
+	}
+	return
+}
+
+// customer return the SSE-C algorithm and key of the Object, the SDK adds the key MD5
+func (o sseOptions) customer() (algorithm, key *string) {
+	if o.customerKey == "" {
+		return nil, nil
+	}
+	return aws.String(s3.ServerSideEncryptionAes256), aws.String(o.customerKey)
+}
+
+// copySource return the SSE-C algorithm and key of the copy source Object
+func (o sseOptions) copySource() (algorithm, key *string) {
+	if o.sourceKey == "" {
+		return nil, nil
+	}
+	return aws.String(s3.ServerSideEncryptionAes256), aws.String(o.sourceKey)
+}
+
+// etagIsChecksum report whether the ETag of a Object may be the checksum of its contents,
+// it is not for Objects encrypted with SSE-KMS or SSE-C
+func etagIsChecksum(sse, sseCustomer string) bool {
+	return sse != s3.ServerSideEncryptionAwsKms && sseCustomer == ""
+}
+
+// contentETag return the ETag if it may be the MD5 of the contents, empty if the Object is encrypted with SSE-KMS or SSE-C
+func contentETag(head *s3.HeadObjectOutput) string {
+	if !etagIsChecksum(aws.StringValue(head.ServerSideEncryption), aws.StringValue(head.SSECustomerAlgorithm)) {
+		return ""
+	}
+	return trimETag(head.ETag)
+}
+
+// bucketEncryptionGet print the default encryption of a Bucket
+func (sc *S3Cli) bucketEncryptionGet(bucket string) error {
+	req, resp := sc.Client.GetBucketEncryptionRequest(&s3.GetBucketEncryptionInput{
+		Bucket: aws.String(bucket),
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	out := encryptionOutput{Bucket: bucket}
+	if err := req.Send(); err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != errNoSuchEncryption {
+			return fmt.Errorf("get bucket encryption failed: %w", err)
+		}
+	} else if cfg := resp.ServerSideEncryptionConfiguration; cfg != nil && len(cfg.Rules) > 0 {
+		rule := cfg.Rules[0]
+		if d := rule.ApplyServerSideEncryptionByDefault; d != nil {
+			out.SSEAlgorithm = aws.StringValue(d.SSEAlgorithm)
+			out.KMSKeyID = aws.StringValue(d.KMSMasterKeyID)
+		}
+		out.BucketKeyEnabled = aws.BoolValue(rule.BucketKeyEnabled)
+	}
+	return sc.printOutput(out, func() {
+		if out.SSEAlgorithm == "" {
+			fmt.Printf("no default encryption of %s\n", bucket)
+			return
+		}
+		fmt.Println(out.SSEAlgorithm)
+		if out.KMSKeyID != "" {
+			fmt.Printf("KMS key ID: %s\n", out.KMSKeyID)
+		}
+		if out.BucketKeyEnabled {
+			fmt.Println("Bucket Key: Enabled")
+		}
+	})
+}
+
+// bucketEncryptionSet set the default encryption(SSE-S3 or SSE-KMS with kmsKeyID) of a Bucket
+func (sc *S3Cli) bucketEncryptionSet(bucket, sse, kmsKeyID string, bucketKey bool) error {
+	sse, err := validSSE(sse, kmsKeyID)
+	if err != nil {
+		return err
+	}
+	if sse == "" {
+		return fmt.Errorf("SSE is required(AES256, aws:kms)")
+	}
+	rule := &s3.ServerSideEncryptionRule{
+		ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+			SSEAlgorithm: aws.String(sse),
+		},
+	}
+	if kmsKeyID != "" {
+		rule.ApplyServerSideEncryptionByDefault.KMSMasterKeyID = aws.String(kmsKeyID)
+	}
+	if bucketKey {
+		rule.BucketKeyEnabled = aws.Bool(true)
+	}
+	req, resp := sc.Client.PutBucketEncryptionRequest(&s3.PutBucketEncryptionInput{
+		Bucket: aws.String(bucket),
+		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+			Rules: []*s3.ServerSideEncryptionRule{rule},
+		},
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("put bucket encryption failed: %w", err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// bucketEncryptionDelete delete the default encryption of a Bucket
+func (sc *S3Cli) bucketEncryptionDelete(bucket string) error {
+	req, resp := sc.Client.DeleteBucketEncryptionRequest(&s3.DeleteBucketEncryptionInput{
+		Bucket: aws.String(bucket),
+	})
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("delete bucket encryption failed: %w", err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
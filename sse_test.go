@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func Test_validSSE(t *testing.T) {
+	cases := []struct {
+		sse, kmsKeyID, expect string
+	}{
+		{"", "", ""},
+		{"aes256", "", s3.ServerSideEncryptionAes256},
+		{"AWS:KMS", "", s3.ServerSideEncryptionAwsKms},
+		{"aws:kms", "key-id", s3.ServerSideEncryptionAwsKms},
+		{"", "key-id", s3.ServerSideEncryptionAwsKms},
+	}
+	for _, c := range cases {
+		if got, err := validSSE(c.sse, c.kmsKeyID); err != nil || got != c.expect {
+			t.Errorf("validSSE(%s, %s) expect: %s, got: %s, %v", c.sse, c.kmsKeyID, c.expect, got, err)
+		}
+	}
+	for _, c := range [][2]string{{"AES256", "key-id"}, {"aws:kms:dsse", ""}, {"SSE-C", ""}} {
+		if _, err := validSSE(c[0], c[1]); err == nil {
+			t.Errorf("expect error for SSE %s, KMS key ID %s", c[0], c[1])
+		}
+	}
+}
+
+func Test_loadSSECKey(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{'k'}, sseCustomerKeySize)
+	files := map[string][]byte{
+		"raw":    key,
+		"base64": []byte(base64.StdEncoding.EncodeToString(key) + "\n"),
+		"short":  key[1:],
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatalf("WriteFile failed: %s", err)
+		}
+	}
+	for _, name := range []string{"raw", "base64"} {
+		if got, err := loadSSECKey(filepath.Join(dir, name)); err != nil || got != string(key) {
+			t.Errorf("loadSSECKey %s expect: %s, got: %s, %v", name, key, got, err)
+		}
+	}
+	for _, name := range []string{"short", "not-exist"} {
+		if _, err := loadSSECKey(filepath.Join(dir, name)); err == nil {
+			t.Errorf("expect error for key file %s", name)
+		}
+	}
+}
+
+func Test_copyObjectInput(t *testing.T) {
+	sc := S3Cli{sse: sseOptions{sse: s3.ServerSideEncryptionAwsKms, kmsKeyID: "key-id", sourceKey: "source-key"}}
+	input := sc.copyObjectInput("bucket", "a b", "bucket2", "key")
+	if aws.StringValue(input.CopySource) != "bucket/a%20b" || aws.StringValue(input.ServerSideEncryption) != s3.ServerSideEncryptionAwsKms ||
+		aws.StringValue(input.SSEKMSKeyId) != "key-id" || input.SSECustomerKey != nil {
+		t.Errorf("unexpected copy input: %s", input)
+	}
+	if aws.StringValue(input.CopySourceSSECustomerAlgorithm) != s3.ServerSideEncryptionAes256 ||
+		aws.StringValue(input.CopySourceSSECustomerKey) != "source-key" {
+		t.Errorf("expect copy source SSE-C, got: %s", input)
+	}
+
+	if sse, kmsKeyID := (sseOptions{}).encryption(); sse != nil || kmsKeyID != nil {
+		t.Errorf("expect no SSE, got: %v, %v", sse, kmsKeyID)
+	}
+	if algorithm, key := (sseOptions{customerKey: "k"}).customer(); aws.StringValue(algorithm) != s3.ServerSideEncryptionAes256 || aws.StringValue(key) != "k" {
+		t.Errorf("expect SSE-C AES256, got: %v, %v", algorithm, key)
+	}
+}
+
+func Test_contentETag(t *testing.T) {
+	etag := aws.String(`"d41d8cd98f00b204e9800998ecf8427e"`)
+	cases := []struct {
+		head   *s3.HeadObjectOutput
+		expect string
+	}{
+		{&s3.HeadObjectOutput{ETag: etag}, "d41d8cd98f00b204e9800998ecf8427e"},
+		{&s3.HeadObjectOutput{ETag: etag, ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256)}, "d41d8cd98f00b204e9800998ecf8427e"},
+		{&s3.HeadObjectOutput{ETag: etag, ServerSideEncryption: aws.String(s3.ServerSideEncryptionAwsKms)}, ""},
+		{&s3.HeadObjectOutput{ETag: etag, SSECustomerAlgorithm: aws.String(s3.ServerSideEncryptionAes256)}, ""},
+	}
+	for _, c := range cases {
+		if got := contentETag(c.head); got != c.expect {
+			t.Errorf("expect: %s, got: %s", c.expect, got)
+		}
+	}
+}
+
+func Test_putSSE(t *testing.T) {
+	sc := s3cliTest
+	sc.sse = sseOptions{sse: s3.ServerSideEncryptionAes256}
+	key := "testPutSSE"
+	if err := sc.putObject(testBucketName, key, bytes.NewReader(testObjectContent)); err != nil {
+		t.Fatalf("putObject with SSE failed: %s", err)
+	}
+	if err := sc.copyObject(testBucketName+"/"+key, testBucketName, key+"-copy"); err != nil {
+		t.Errorf("copyObject with SSE failed: %s", err)
+	}
+
+	// the SDK refuses to send SSE-C keys over HTTP
+	sc.sse = sseOptions{customerKey: string(bytes.Repeat([]byte{'k'}, sseCustomerKeySize))}
+	if err := sc.putObject(testBucketName, key, bytes.NewReader(testObjectContent)); err == nil {
+		t.Errorf("expect error to send SSE-C key over HTTP")
+	}
+}
+
+func Test_sseCustomerHeaders(t *testing.T) {
+	sc := s3cliTest
+	sc.sse = sseOptions{customerKey: string(bytes.Repeat([]byte{'k'}, sseCustomerKeySize))}
+	// the SDK only sends SSE-C keys over HTTPS, capture the signed requests instead of sending them
+	client, err := newEndpointClient(&sc, "https://127.0.0.1", "", "")
+	if err != nil {
+		t.Fatalf("newEndpointClient failed: %s", err)
+	}
+	headers := map[string]string{}
+	client.Handlers.Send.Clear()
+	client.Handlers.Send.PushBack(func(r *request.Request) {
+		headers[r.Operation.Name] = r.HTTPRequest.Header.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm") + " " +
+			r.HTTPRequest.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key")
+		r.Error = awserr.New("Captured", "request not sent", nil)
+	})
+	sc.Client = client
+
+	sc.getObjectRange(testBucketName, testObjectKey, "", `"etag"`, 0, 1, ioutil.Discard)
+	sc.mpuUploadPart(testBucketName, testObjectKey, "uid", 1, bytes.NewReader(testObjectContent))
+	expect := s3.ServerSideEncryptionAes256 + " " + base64.StdEncoding.EncodeToString([]byte(sc.sse.customerKey))
+	for _, op := range []string{"GetObject", "UploadPart"} {
+		if headers[op] != expect {
+			t.Errorf("%s expect SSE-C headers: %s, got: %s", op, expect, headers[op])
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// syncOptions control how sync compares and transfers files/Objects
+type syncOptions struct {
+	delete   bool // delete extraneous destination files/Objects
+	dryRun   bool // only print what would be done
+	checksum bool // compare local MD5 with single-part ETag
+	jobs     int  // number of parallel transfers
+}
+
+// syncEntry a file or Object to compare
+type syncEntry struct {
+	size  int64
+	mtime time.Time
+	etag  string
+}
+
+// syncAction a transfer or delete to do
+type syncAction struct {
+	op     string // upload, download or delete
+	source string // local file or key
+	dest   string // local file or key
+	size   int64  // size of the transferred file/Object
+	err    error
+}
+
+// fileMD5 calculate the hex MD5 of a local file
+func fileMD5(filename string) (string, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, fd); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// localEntries list all regular files under dir, keyed by slash separated relative path
+func localEntries(dir string) (map[string]syncEntry, error) {
+	entries := map[string]syncEntry{}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return entries, nil
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		entries[filepath.ToSlash(rel)] = syncEntry{
+			size:  info.Size(),
+			mtime: info.ModTime(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s failed: %w", dir, err)
+	}
+	return entries, nil
+}
+
+// remoteEntries list all Objects with prefix, keyed by the key without prefix
+func (sc *S3Cli) remoteEntries(bucket, prefix string) (map[string]syncEntry, error) {
+	entries := map[string]syncEntry{}
+	err := sc.walkObjects(bucket, prefix, func(obj *s3.Object) error {
+		if strings.HasSuffix(*obj.Key, "/") { // directory placeholder
+			return nil
+		}
+		entries[strings.TrimPrefix(*obj.Key, prefix)] = syncEntry{
+			size:  aws.Int64Value(obj.Size),
+			mtime: aws.TimeValue(obj.LastModified),
+			etag:  strings.Trim(aws.StringValue(obj.ETag), `"`),
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// syncChanged report whether a local file and a Object differ, newer is the mtime comparison result
+func syncChanged(local, remote syncEntry, localFile string, newer, checksum bool) bool {
+	if local.size != remote.size {
+		return true
+	}
+	// multipart ETag is not the MD5 of the Object, fall back to mtime
+	if checksum && remote.etag != "" && !strings.Contains(remote.etag, "-") {
+		sum, err := fileMD5(localFile)
+		return err != nil || sum != remote.etag
+	}
+	return newer
+}
+
+// syncPrefix make prefix a directory-like prefix
+func syncPrefix(prefix string) string {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// syncUp sync local dir to bucket/prefix
+func (sc *S3Cli) syncUp(dir, bucket, prefix string, opt syncOptions) error {
+	prefix = syncPrefix(prefix)
+	local, err := localEntries(dir)
+	if err != nil {
+		return err
+	}
+	remote, err := sc.remoteEntries(bucket, prefix)
+	if err != nil {
+		return err
+	}
+
+	actions := []syncAction{}
+	for rel, l := range local {
+		filename := filepath.Join(dir, filepath.FromSlash(rel))
+		r, ok := remote[rel]
+		if ok && !syncChanged(l, r, filename, l.mtime.Truncate(time.Second).After(r.mtime), opt.checksum) {
+			continue
+		}
+		actions = append(actions, syncAction{op: "upload", source: filename, dest: prefix + rel, size: l.size})
+	}
+	if opt.delete {
+		for rel := range remote {
+			if _, ok := local[rel]; !ok {
+				actions = append(actions, syncAction{op: "delete", dest: prefix + rel})
+			}
+		}
+	}
+
+	return sc.syncRun(actions, opt, func(a *syncAction) error {
+		if a.op == "delete" {
+			return sc.deleteObject(bucket, a.dest, "")
+		}
+		return sc.putFile(bucket, a.dest, a.source)
+	})
+}
+
+// syncDown sync bucket/prefix to local dir
+func (sc *S3Cli) syncDown(bucket, prefix, dir string, opt syncOptions) error {
+	prefix = syncPrefix(prefix)
+	remote, err := sc.remoteEntries(bucket, prefix)
+	if err != nil {
+		return err
+	}
+	local, err := localEntries(dir)
+	if err != nil {
+		return err
+	}
+
+	actions := []syncAction{}
+	for rel, r := range remote {
+		filename, err := localPath(dir, prefix, prefix+rel)
+		if err != nil {
+			actions = append(actions, syncAction{op: "download", source: prefix + rel, err: err})
+			continue
+		}
+		l, ok := local[rel]
+		if ok && !syncChanged(l, r, filename, r.mtime.After(l.mtime.Truncate(time.Second)), opt.checksum) {
+			continue
+		}
+		actions = append(actions, syncAction{op: "download", source: prefix + rel, dest: filename, size: r.size})
+	}
+	if opt.delete {
+		for rel := range local {
+			if _, ok := remote[rel]; !ok {
+				actions = append(actions, syncAction{op: "delete", dest: filepath.Join(dir, filepath.FromSlash(rel))})
+			}
+		}
+	}
+
+	return sc.syncRun(actions, opt, func(a *syncAction) error {
+		if a.op == "delete" {
+			return os.Remove(a.dest)
+		}
+		return sc.downloadObject(bucket, a.source, a.dest, remote[strings.TrimPrefix(a.source, prefix)].mtime)
+	})
+}
+
+// syncRun do(or print in dry-run mode) all sync actions and print a summary
+func (sc *S3Cli) syncRun(actions []syncAction, opt syncOptions, fn func(a *syncAction) error) error {
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].op != actions[j].op {
+			return actions[i].op > actions[j].op // transfer before delete
+		}
+		return actions[i].source+actions[i].dest < actions[j].source+actions[j].dest
+	})
+	if !opt.dryRun {
+		var size, files int64
+		for _, a := range actions {
+			if a.op != "delete" && a.err == nil {
+				size += a.size
+				files++
+			}
+		}
+		started := sc.startProgress(size, files)
+		parallel(opt.jobs, len(actions), func(i int) {
+			a := &actions[i]
+			if a.err != nil {
+				return
+			}
+			a.err = fn(a)
+		})
+		if started {
+			sc.stopProgress()
+		}
+	}
+
+	out := syncOutput{DryRun: opt.dryRun, Actions: []syncActionOutput{}}
+	for _, a := range actions {
+		ao := syncActionOutput{Op: a.op, Source: a.source, Dest: a.dest, Status: "ok"}
+		switch {
+		case a.err != nil:
+			out.Failed++
+			ao.Status = "failed"
+			ao.Error = a.err.Error()
+		case opt.dryRun:
+			ao.Status = "dry-run"
+		case a.op == "delete":
+			out.Deleted++
+		default:
+			out.Transferred++
+		}
+		out.Actions = append(out.Actions, ao)
+	}
+	err := sc.printOutput(out, func() {
+		for _, a := range actions {
+			switch {
+			case opt.dryRun:
+				fmt.Printf("(dry-run) %s\t%s\n", a.op, syncActionString(a))
+			case a.err != nil:
+				fmt.Printf("failed\t%s %s: %s\n", a.op, syncActionString(a), a.err)
+			default:
+				fmt.Printf("%s\t%s\n", a.op, syncActionString(a))
+			}
+		}
+		if !opt.dryRun {
+			fmt.Printf("%d transferred, %d deleted, %d failed\n", out.Transferred, out.Deleted, out.Failed)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if out.Failed > 0 {
+		return fmt.Errorf("%d of %d sync action(s) failed", out.Failed, len(actions))
+	}
+	return nil
+}
+
+// syncActionString format source and dest of a sync action
+func syncActionString(a syncAction) string {
+	if a.op == "delete" {
+		return a.dest
+	}
+	return fmt.Sprintf("%s -> %s", a.source, a.dest)
+}
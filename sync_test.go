@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_syncChanged(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		local, remote syncEntry
+		newer         bool
+		expect        bool
+	}{
+		{syncEntry{size: 1, mtime: now}, syncEntry{size: 2, mtime: now}, false, true},
+		{syncEntry{size: 1, mtime: now}, syncEntry{size: 1, mtime: now}, false, false},
+		{syncEntry{size: 1, mtime: now}, syncEntry{size: 1, mtime: now}, true, true},
+	}
+	for i, v := range cases {
+		if got := syncChanged(v.local, v.remote, "", v.newer, false); got != v.expect {
+			t.Errorf("case %d expect: %v, got: %v", i, v.expect, got)
+		}
+	}
+}
+
+func Test_syncUp(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "file1"), testObjectContent, 0644); err != nil {
+		t.Errorf("syncUp WriteFile failed: %s", err)
+		return
+	}
+	prefix := "testSyncUp/"
+	extraKey := prefix + "extra"
+	if _, err := s3Backend.PutObject(testBucketName, extraKey, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+		t.Errorf("syncUp backend PutObject failed: %s", err)
+		return
+	}
+
+	opt := syncOptions{delete: true, dryRun: true, jobs: 2}
+	if err := s3cliTest.syncUp(dir, testBucketName, prefix, opt); err != nil {
+		t.Errorf("syncUp dry-run failed: %s", err)
+		return
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, prefix+"file1"); err == nil {
+		t.Errorf("syncUp dry-run uploaded file1")
+	}
+
+	opt.dryRun = false
+	if err := s3cliTest.syncUp(dir, testBucketName, prefix, opt); err != nil {
+		t.Errorf("syncUp failed: %s", err)
+		return
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, prefix+"file1"); err != nil {
+		t.Errorf("syncUp backend HeadObject failed: %s", err)
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, extraKey); err == nil {
+		t.Errorf("syncUp did not delete %s", extraKey)
+	}
+}
+
+func Test_syncDown(t *testing.T) {
+	prefix := "testSyncDown/"
+	if _, err := s3Backend.PutObject(testBucketName, prefix+"sub/file1", nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent))); err != nil {
+		t.Errorf("syncDown backend PutObject failed: %s", err)
+		return
+	}
+	dir := t.TempDir()
+	extraFile := filepath.Join(dir, "extra")
+	if err := ioutil.WriteFile(extraFile, testObjectContent, 0644); err != nil {
+		t.Errorf("syncDown WriteFile failed: %s", err)
+		return
+	}
+
+	opt := syncOptions{delete: true, checksum: true, jobs: 2}
+	if err := s3cliTest.syncDown(testBucketName, prefix, dir, opt); err != nil {
+		t.Errorf("syncDown failed: %s", err)
+		return
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "sub", "file1"))
+	if err != nil {
+		t.Errorf("syncDown ReadFile failed: %s", err)
+		return
+	}
+	if !bytes.Equal(data, testObjectContent) {
+		t.Errorf("expect %s, got %s", testObjectContent, data)
+	}
+	if _, err := os.Stat(extraFile); !os.IsNotExist(err) {
+		t.Errorf("syncDown did not delete %s", extraFile)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// operations of tag set/add/remove
+const (
+	tagOpSet    = "set"    // replace all tags
+	tagOpAdd    = "add"    // add tags or replace the values of existing keys
+	tagOpRemove = "remove" // remove tags of keys
+)
+
+const (
+	maxObjectTags     = 10
+	maxBucketTags     = 50
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+	// errNoSuchTagSet error code of a Bucket without tags
+	errNoSuchTagSet = "NoSuchTagSet"
+	// tagBatchKeys max listed keys tagged by parallel workers at a time
+	tagBatchKeys = 1000
+)
+
+// validTags check tags locally before they are sent, at most max tags with unique keys
+func validTags(tags []*s3.Tag, max int) error {
+	if len(tags) > max {
+		return fmt.Errorf("too many tags(> %d)", max)
+	}
+	keys := map[string]bool{}
+	for _, t := range tags {
+		k, v := aws.StringValue(t.Key), aws.StringValue(t.Value)
+		if k == "" {
+			return errors.New("empty tag key")
+		}
+		if utf8.RuneCountInString(k) > maxTagKeyLength {
+			return fmt.Errorf("tag key %s longer than %d characters", k, maxTagKeyLength)
+		}
+		if utf8.RuneCountInString(v) > maxTagValueLength {
+			return fmt.Errorf("tag value of %s longer than %d characters", k, maxTagValueLength)
+		}
+		if keys[k] {
+			return fmt.Errorf("duplicate tag key %s", k)
+		}
+		keys[k] = true
+	}
+	return nil
+}
+
+// mergeTags apply op with tags to old tags, the order of old tags is kept
+func mergeTags(old, tags []*s3.Tag, op string) []*s3.Tag {
+	if op == tagOpSet {
+		return tags
+	}
+	updates := map[string]*s3.Tag{}
+	for _, t := range tags {
+		updates[aws.StringValue(t.Key)] = t
+	}
+	result := make([]*s3.Tag, 0, len(old)+len(tags))
+	for _, t := range old {
+		k := aws.StringValue(t.Key)
+		if u, ok := updates[k]; ok {
+			if op == tagOpAdd {
+				result = append(result, u)
+			}
+			delete(updates, k)
+			continue
+		}
+		result = append(result, t)
+	}
+	if op == tagOpAdd {
+		for _, t := range tags {
+			if _, ok := updates[aws.StringValue(t.Key)]; ok {
+				result = append(result, t)
+			}
+		}
+	}
+	return result
+}
+
+// keyTags return tags of keys without value, to remove them
+func keyTags(keys []string) []*s3.Tag {
+	tags := make([]*s3.Tag, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, &s3.Tag{Key: aws.String(k)})
+	}
+	return tags
+}
+
+// encodeTags encode tags to the x-amz-tagging header(URL query) of put Object
+func encodeTags(tags []*s3.Tag) string {
+	kvs := make([]string, 0, len(tags))
+	for _, t := range tags {
+		kvs = append(kvs, url.QueryEscape(aws.StringValue(t.Key))+"="+url.QueryEscape(aws.StringValue(t.Value)))
+	}
+	return strings.Join(kvs, "&")
+}
+
+// taggingHeader return the x-amz-tagging header of uploaded Objects, nil if no tag
+func (sc *S3Cli) taggingHeader() *string {
+	if sc.tagging == "" {
+		return nil
+	}
+	return aws.String(sc.tagging)
+}
+
+// tagMap convert tags to a map for output
+func tagMap(tags []*s3.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, t := range tags {
+		m[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return m
+}
+
+// getTags get the tags of a Object, or a Bucket if key is empty
+func (sc *S3Cli) getTags(bucket, key string) ([]*s3.Tag, error) {
+	if key == "" {
+		resp, err := sc.Client.GetBucketTagging(&s3.GetBucketTaggingInput{
+			Bucket: aws.String(bucket),
+		})
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errNoSuchTagSet {
+				return []*s3.Tag{}, nil
+			}
+			return nil, fmt.Errorf("get bucket tagging failed: %w", err)
+		}
+		return resp.TagSet, nil
+	}
+	resp, err := sc.Client.GetObjectTagging(&s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get object tagging failed: %w", err)
+	}
+	return resp.TagSet, nil
+}
+
+// putTags replace the tags of a Object, or a Bucket if key is empty, delete the tagging if no tag
+func (sc *S3Cli) putTags(bucket, key string, tags []*s3.Tag) error {
+	var req *request.Request
+	var resp interface{}
+	op := "put"
+	switch {
+	case key == "" && len(tags) == 0:
+		op = "delete"
+		req, resp = sc.Client.DeleteBucketTaggingRequest(&s3.DeleteBucketTaggingInput{
+			Bucket: aws.String(bucket),
+		})
+	case key == "":
+		req, resp = sc.Client.PutBucketTaggingRequest(&s3.PutBucketTaggingInput{
+			Bucket:  aws.String(bucket),
+			Tagging: &s3.Tagging{TagSet: tags},
+		})
+	case len(tags) == 0:
+		op = "delete"
+		req, resp = sc.Client.DeleteObjectTaggingRequest(&s3.DeleteObjectTaggingInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+	default:
+		req, resp = sc.Client.PutObjectTaggingRequest(&s3.PutObjectTaggingInput{
+			Bucket:  aws.String(bucket),
+			Key:     aws.String(key),
+			Tagging: &s3.Tagging{TagSet: tags},
+		})
+	}
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("%s tagging failed: %w", op, err)
+	}
+	if sc.verbose && sc.textOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// tagKey set/add/remove tags of a Object, or a Bucket if key is empty
+func (sc *S3Cli) tagKey(bucket, key, op string, tags []*s3.Tag) error {
+	max := maxObjectTags
+	if key == "" {
+		max = maxBucketTags
+	}
+	if op != tagOpSet {
+		old, err := sc.getTags(bucket, key)
+		if err != nil {
+			return err
+		}
+		tags = mergeTags(old, tags, op)
+	}
+	if err := validTags(tags, max); err != nil {
+		return err
+	}
+	return sc.putTags(bucket, key, tags)
+}
+
+// tagGet print the tags of a Object, or a Bucket if key is empty
+func (sc *S3Cli) tagGet(bucket, key string) error {
+	if sc.presign {
+		req, _ := sc.Client.GetObjectTaggingRequest(&s3.GetObjectTaggingInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		if key == "" {
+			req, _ = sc.Client.GetBucketTaggingRequest(&s3.GetBucketTaggingInput{
+				Bucket: aws.String(bucket),
+			})
+		}
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+	tags, err := sc.getTags(bucket, key)
+	if err != nil {
+		return err
+	}
+	return sc.printOutput(tagOutput{Bucket: bucket, Key: key, Tags: tagMap(tags)}, func() {
+		for _, t := range tags {
+			fmt.Printf("%s=%s\n", aws.StringValue(t.Key), aws.StringValue(t.Value))
+		}
+	})
+}
+
+// tagObjects set/add/remove tags of all Objects with prefix by jobs parallel workers, prefix is
+// a directory(ends with /), so logs does not tag logs-old/
+func (sc *S3Cli) tagObjects(bucket, prefix, op string, tags []*s3.Tag, jobs int) error {
+	if sc.presign {
+		return fmt.Errorf("--presign is not supported by recursive tag")
+	}
+	prefix = syncPrefix(prefix)
+	out := tagObjectsOutput{
+		Bucket:  bucket,
+		Prefix:  prefix,
+		Op:      op,
+		Objects: []tagResultOutput{},
+	}
+	var keys []string
+	flush := func() {
+		errs := make([]error, len(keys))
+		parallel(jobs, len(keys), func(i int) {
+			errs[i] = sc.tagKey(bucket, keys[i], op, tags)
+		})
+		for i, key := range keys {
+			o := tagResultOutput{Key: key, Status: "ok"}
+			if errs[i] != nil {
+				o.Status = "failed"
+				o.Error = errs[i].Error()
+				out.Failed++
+			} else {
+				out.Tagged++
+			}
+			if sc.textOutput() {
+				if errs[i] != nil {
+					fmt.Fprintf(os.Stderr, "tag %s failed: %s\n", key, errs[i])
+				} else if sc.verbose {
+					fmt.Println(key)
+				}
+			} else {
+				out.Objects = append(out.Objects, o)
+			}
+		}
+		keys = keys[:0]
+	}
+
+	err := sc.walkObjects(bucket, prefix, func(obj *s3.Object) error {
+		keys = append(keys, aws.StringValue(obj.Key))
+		if len(keys) >= tagBatchKeys {
+			flush()
+		}
+		return nil
+	})
+	flush()
+	if err != nil {
+		return err
+	}
+	if err := sc.printOutput(out, func() {
+		fmt.Printf("%s tags of %d Object(s)\n", op, out.Tagged)
+	}); err != nil {
+		return err
+	}
+	if out.Failed > 0 {
+		return fmt.Errorf("tag %d of %d Objects failed", out.Failed, out.Tagged+out.Failed)
+	}
+	return nil
+}
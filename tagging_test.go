@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func Test_validTags(t *testing.T) {
+	tags, _ := parseTags([]string{"k1=v1", "k2="})
+	if err := validTags(tags, maxObjectTags); err != nil {
+		t.Errorf("expect valid tags, got: %s", err)
+	}
+	for _, v := range [][]string{
+		{"k=1", "k=2"},
+		{strings.Repeat("k", maxTagKeyLength+1) + "=v"},
+		{"k=" + strings.Repeat("v", maxTagValueLength+1)},
+		{"k1=", "k2=", "k3=", "k4=", "k5=", "k6=", "k7=", "k8=", "k9=", "k10=", "k11="},
+	} {
+		tags, _ := parseTags(v)
+		if err := validTags(tags, maxObjectTags); err == nil {
+			t.Errorf("expect error for tags: %v", v)
+		}
+	}
+}
+
+func Test_mergeTags(t *testing.T) {
+	old, _ := parseTags([]string{"a=1", "b=2", "c=3"})
+	tags, _ := parseTags([]string{"b=20", "d=4"})
+	cases := map[string]map[string]string{
+		tagOpSet:    {"b": "20", "d": "4"},
+		tagOpAdd:    {"a": "1", "b": "20", "c": "3", "d": "4"},
+		tagOpRemove: {"a": "1", "c": "3"},
+	}
+	for op, expect := range cases {
+		if got := tagMap(mergeTags(old, tags, op)); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%s expect: %v, got: %v", op, expect, got)
+		}
+	}
+	if got := mergeTags(old, tags, tagOpAdd); aws.StringValue(got[1].Value) != "20" || aws.StringValue(got[3].Key) != "d" {
+		t.Errorf("expect old order kept and new tags appended, got: %v", got)
+	}
+}
+
+func Test_encodeTags(t *testing.T) {
+	tags, _ := parseTags([]string{"team=data eng", "a&b=1=2"})
+	if got, expect := encodeTags(tags), "team=data+eng&a%26b=1%3D2"; got != expect {
+		t.Errorf("expect: %s, got: %s", expect, got)
+	}
+}
+
+func Test_tagKey(t *testing.T) {
+	sc := s3cliTest
+	sc.output = outputJSON
+	key := "testTagKey"
+	if err := sc.putObject(testBucketName, key, bytes.NewReader(testObjectContent)); err != nil {
+		t.Fatalf("putObject failed: %s", err)
+	}
+	steps := []struct {
+		op     string
+		tags   []string
+		expect map[string]string
+	}{
+		{tagOpSet, []string{"a=1", "b=2"}, map[string]string{"a": "1", "b": "2"}},
+		{tagOpAdd, []string{"b=3", "c=4"}, map[string]string{"a": "1", "b": "3", "c": "4"}},
+		{tagOpRemove, []string{"a", "b", "c"}, map[string]string{}},
+	}
+	for _, step := range steps {
+		tags := keyTags(step.tags)
+		if step.op != tagOpRemove {
+			tags, _ = parseTags(step.tags)
+		}
+		if err := sc.tagKey(testBucketName, key, step.op, tags); err != nil {
+			t.Fatalf("tag %s failed: %s", step.op, err)
+		}
+		got, err := sc.getTags(testBucketName, key)
+		if err != nil {
+			t.Fatalf("getTags failed: %s", err)
+		}
+		if m := tagMap(got); !reflect.DeepEqual(m, step.expect) {
+			t.Errorf("tag %s expect: %v, got: %v", step.op, step.expect, m)
+		}
+	}
+	if err := sc.tagGet(testBucketName, key); err != nil {
+		t.Errorf("tagGet failed: %s", err)
+	}
+
+	// Bucket without tags
+	if tags, err := sc.getTags(testBucketName, ""); err != nil || len(tags) != 0 {
+		t.Errorf("expect no Bucket tags, got: %v, %v", tags, err)
+	}
+}
+
+func Test_tagObjects(t *testing.T) {
+	sc := s3cliTest
+	sc.output = outputJSON
+	prefix := "testTagObjects/"
+	for _, k := range []string{prefix + "a", prefix + "b", prefix + "c/d", "testTagObjects-old/a"} {
+		if err := sc.putObject(testBucketName, k, bytes.NewReader(testObjectContent)); err != nil {
+			t.Fatalf("putObject failed: %s", err)
+		}
+	}
+	tags, _ := parseTags([]string{"class=archive"})
+	if err := sc.tagObjects(testBucketName, "testTagObjects", tagOpAdd, tags, 2); err != nil {
+		t.Fatalf("tagObjects failed: %s", err)
+	}
+	for _, k := range []string{"a", "b", "c/d"} {
+		got, err := sc.getTags(testBucketName, prefix+k)
+		if err != nil || tagMap(got)["class"] != "archive" {
+			t.Errorf("expect %s tagged, got: %v, %v", k, got, err)
+		}
+	}
+	if got, err := sc.getTags(testBucketName, "testTagObjects-old/a"); err != nil || len(got) != 0 {
+		t.Errorf("expect sibling testTagObjects-old/a not tagged, got: %v, %v", got, err)
+	}
+}
+
+func Test_putTagging(t *testing.T) {
+	sc := s3cliTest
+	tags, _ := parseTags([]string{"team=data"})
+	sc.tagging = encodeTags(tags)
+	key := "testPutTagging"
+	if err := sc.putObject(testBucketName, key, bytes.NewReader(testObjectContent)); err != nil {
+		t.Fatalf("putObject failed: %s", err)
+	}
+	got, err := sc.getTags(testBucketName, key)
+	if err != nil || tagMap(got)["team"] != "data" {
+		t.Errorf("expect put with tags, got: %v, %v", got, err)
+	}
+}
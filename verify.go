@@ -0,0 +1,268 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// additional checksum algorithms of PutObject(x-amz-checksum-*)
+const (
+	checksumCRC32C = "CRC32C"
+	checksumSHA256 = "SHA256"
+)
+
+// partSizeMetaKey user metadata(x-amz-meta-s3cli-part-size) of the part size of multipart uploaded Objects,
+// the multipart ETag(MD5 of the part MD5s) is verified with it
+const partSizeMetaKey = "S3cli-Part-Size"
+
+var (
+	// md5ETag match the ETag of single-part Objects(MD5 of the contents)
+	md5ETag = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	// multipartETag match the ETag of multipart Objects(MD5 of the part MD5s and the number of parts)
+	multipartETag = regexp.MustCompile(`^[0-9a-f]{32}-[0-9]+$`)
+)
+
+// validChecksum check the additional checksum algorithm, empty means no additional checksum
+func validChecksum(algorithm string) error {
+	switch algorithm {
+	case "", checksumCRC32C, checksumSHA256:
+		return nil
+	}
+	return fmt.Errorf("invalid checksum algorithm %q, expect %s or %s", algorithm, checksumCRC32C, checksumSHA256)
+}
+
+// multipartChecksum refuse the additional checksum of Multi-Part-Upload, CompleteMultipartUpload of the SDK
+// can not send the part checksums it requires
+func (sc *S3Cli) multipartChecksum() error {
+	if sc.checksum != "" {
+		return fmt.Errorf("--checksum-algorithm %s is not supported by Multi-Part-Upload, increase --threshold or --part-size", sc.checksum)
+	}
+	return nil
+}
+
+// hashReadSeeker write all contents of r to hashes and seek r back to where it was
+func hashReadSeeker(r io.ReadSeeker, hashes ...hash.Hash) error {
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	ws := make([]io.Writer, len(hashes))
+	for i, h := range hashes {
+		ws[i] = h
+	}
+	if _, err = io.Copy(io.MultiWriter(ws...), r); err != nil {
+		return err
+	}
+	_, err = r.Seek(offset, io.SeekStart)
+	return err
+}
+
+// setChecksum set Content-MD5 and the additional checksum(x-amz-checksum-*, if algorithm is not empty)
+// header of body to req, nothing is set with --no-verify
+func (sc *S3Cli) setChecksum(req *request.Request, body io.ReadSeeker, algorithm string) error {
+	if sc.noVerify || body == nil {
+		return nil
+	}
+	sum := md5.New()
+	hashes := []hash.Hash{sum}
+	var extra hash.Hash
+	switch algorithm {
+	case checksumCRC32C:
+		extra = crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case checksumSHA256:
+		extra = sha256.New()
+	}
+	if extra != nil {
+		hashes = append(hashes, extra)
+	}
+	if err := hashReadSeeker(body, hashes...); err != nil {
+		return fmt.Errorf("checksum failed: %w", err)
+	}
+	req.HTTPRequest.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum.Sum(nil)))
+	if extra != nil {
+		req.HTTPRequest.Header.Set("x-amz-checksum-"+strings.ToLower(algorithm), base64.StdEncoding.EncodeToString(extra.Sum(nil)))
+	}
+	return nil
+}
+
+// partSizeMetadata return a copy of metadata with the part size(if > 0) of a multipart upload,
+// the part size of the source(copy) Object is dropped, and not added with --no-verify
+func (sc *S3Cli) partSizeMetadata(metadata map[string]*string, partSize int64) map[string]*string {
+	m := make(map[string]*string, len(metadata)+1)
+	for k, v := range metadata {
+		if !strings.EqualFold(k, partSizeMetaKey) {
+			m[k] = v
+		}
+	}
+	if partSize > 0 && !sc.noVerify {
+		m[partSizeMetaKey] = aws.String(strconv.FormatInt(partSize, 10))
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// multipartHash compute the multipart ETag(without -N) of the contents uploaded in partSize parts
+type multipartHash struct {
+	partSize int64
+	part     hash.Hash // MD5 of the current part
+	n        int64     // written bytes of the current part
+	sums     []byte    // MD5s of the finished parts
+}
+
+func newMultipartHash(partSize int64) *multipartHash {
+	return &multipartHash{partSize: partSize, part: md5.New()}
+}
+
+func (h *multipartHash) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		k := h.partSize - h.n
+		if k > int64(len(p)) {
+			k = int64(len(p))
+		}
+		h.part.Write(p[:k])
+		h.n += k
+		p = p[k:]
+		if h.n == h.partSize {
+			h.sums = h.part.Sum(h.sums)
+			h.part.Reset()
+			h.n = 0
+		}
+	}
+	return written, nil
+}
+
+// Sum append the MD5 of the part MD5s to b
+func (h *multipartHash) Sum(b []byte) []byte {
+	sums := h.sums
+	if h.n > 0 {
+		sums = h.part.Sum(append([]byte{}, sums...))
+	}
+	sum := md5.Sum(sums)
+	return append(b, sum[:]...)
+}
+
+// parts return the number of parts written
+func (h *multipartHash) parts() int {
+	n := len(h.sums) / md5.Size
+	if h.n > 0 {
+		n++
+	}
+	return n
+}
+
+func (h *multipartHash) Reset() {
+	h.part.Reset()
+	h.n = 0
+	h.sums = nil
+}
+
+func (h *multipartHash) Size() int      { return md5.Size }
+func (h *multipartHash) BlockSize() int { return md5.BlockSize }
+
+// verifier check the downloaded contents of a Object
+type verifier struct {
+	hash.Hash
+	name   string // checksum name in error message
+	expect string // expected hex checksum, with -N of multipart ETags
+}
+
+// newVerifier return the verifier of a Object with etag, metadata and server-side encryption,
+// the ETag is the MD5 of the contents if it is single-part, or the MD5 of the part MD5s if the
+// part size is stored in metadata(MPU by s3cli), nil if the Object can not be verified
+func newVerifier(etag string, metadata map[string]*string, sse, sseCustomer string) *verifier {
+	etag = strings.ToLower(strings.Trim(etag, `"`))
+	if !etagIsChecksum(sse, sseCustomer) {
+		return nil
+	}
+	if md5ETag.MatchString(etag) {
+		return &verifier{Hash: md5.New(), name: "MD5(ETag)", expect: etag}
+	}
+	if !multipartETag.MatchString(etag) {
+		return nil
+	}
+	for k, v := range metadata {
+		if !strings.EqualFold(k, partSizeMetaKey) {
+			continue
+		}
+		if partSize, err := strconv.ParseInt(aws.StringValue(v), 10, 64); err == nil && partSize > 0 {
+			return &verifier{Hash: newMultipartHash(partSize), name: "multipart ETag", expect: etag}
+		}
+	}
+	return nil
+}
+
+// verify compare the checksum of written contents with the expected one
+func (v *verifier) verify(bucket, key string) error {
+	got := hex.EncodeToString(v.Sum(nil))
+	if h, ok := v.Hash.(*multipartHash); ok {
+		got += "-" + strconv.Itoa(h.parts())
+	}
+	if got != v.expect {
+		return fmt.Errorf("verify %s/%s failed: %s %s, expect %s(rerun with --no-verify to skip)", bucket, key, v.name, got, v.expect)
+	}
+	return nil
+}
+
+// verifyReader verify the contents read from a Object body when it reaches EOF
+type verifyReader struct {
+	io.ReadCloser
+	v      *verifier
+	bucket string
+	key    string
+}
+
+func (vr *verifyReader) Read(p []byte) (int, error) {
+	n, err := vr.ReadCloser.Read(p)
+	vr.v.Write(p[:n])
+	if err == io.EOF {
+		if verr := vr.v.verify(vr.bucket, vr.key); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
+
+// verifyBody wrap the whole(not range) Object body of resp to verify its contents,
+// body is returned as is with --no-verify or if the Object can not be verified
+func (sc *S3Cli) verifyBody(bucket, key string, resp *s3.GetObjectOutput) io.ReadCloser {
+	// the HTTP client drops Content-Length when it decodes a gzip Content-Encoding transparently
+	if sc.noVerify || resp.ContentLength == nil {
+		return resp.Body
+	}
+	v := newVerifier(aws.StringValue(resp.ETag), resp.Metadata, aws.StringValue(resp.ServerSideEncryption), aws.StringValue(resp.SSECustomerAlgorithm))
+	if v == nil {
+		return resp.Body
+	}
+	return &verifyReader{ReadCloser: resp.Body, v: v, bucket: bucket, key: key}
+}
+
+// verifyFile verify the contents of r(a downloaded file) against the Object head
+func (sc *S3Cli) verifyFile(bucket, key string, r io.Reader, head *s3.HeadObjectOutput) error {
+	if sc.noVerify {
+		return nil
+	}
+	v := newVerifier(aws.StringValue(head.ETag), head.Metadata, aws.StringValue(head.ServerSideEncryption), aws.StringValue(head.SSECustomerAlgorithm))
+	if v == nil {
+		return nil
+	}
+	if _, err := io.Copy(v, r); err != nil {
+		return err
+	}
+	return v.verify(bucket, key)
+}
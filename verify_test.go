@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func Test_validChecksum(t *testing.T) {
+	for _, v := range []string{"", checksumCRC32C, checksumSHA256} {
+		if err := validChecksum(v); err != nil {
+			t.Errorf("validChecksum %s failed: %s", v, err)
+		}
+	}
+	if err := validChecksum("MD5"); err == nil {
+		t.Errorf("expect error for checksum algorithm MD5")
+	}
+}
+
+func Test_newVerifier(t *testing.T) {
+	sum := md5.Sum(testObjectContent)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	partSize := map[string]*string{"S3cli-Part-Size": aws.String("8388608")}
+	cases := []struct {
+		etag        string
+		metadata    map[string]*string
+		sse, sseC   string
+		expectName  string
+		expectValue string
+	}{
+		{etag, nil, "", "", "MD5(ETag)", hex.EncodeToString(sum[:])},
+		{`"0123456789abcdef0123456789abcdef-2"`, nil, "", "", "", ""},
+		{`"0123456789abcdef0123456789abcdef-2"`, partSize, "", "", "multipart ETag", "0123456789abcdef0123456789abcdef-2"},
+		{`"0123456789abcdef0123456789abcdef-2"`, partSize, s3.ServerSideEncryptionAwsKms, "", "", ""},
+		{etag, nil, s3.ServerSideEncryptionAwsKms, "", "", ""},
+		{etag, nil, "", "AES256", "", ""},
+		{etag, nil, s3.ServerSideEncryptionAes256, "", "MD5(ETag)", hex.EncodeToString(sum[:])},
+	}
+	for i, v := range cases {
+		got := newVerifier(v.etag, v.metadata, v.sse, v.sseC)
+		if v.expectName == "" {
+			if got != nil {
+				t.Errorf("case %d expect nil verifier, got: %s", i, got.name)
+			}
+			continue
+		}
+		if got == nil || got.name != v.expectName || got.expect != v.expectValue {
+			t.Errorf("case %d expect: %s %s, got: %+v", i, v.expectName, v.expectValue, got)
+		}
+	}
+}
+
+func Test_multipartHash(t *testing.T) {
+	data := make([]byte, 10)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	var sums []byte
+	for _, part := range [][]byte{data[:4], data[4:8], data[8:]} {
+		sum := md5.Sum(part)
+		sums = append(sums, sum[:]...)
+	}
+	sum := md5.Sum(sums)
+	expect := hex.EncodeToString(sum[:]) + "-3"
+
+	v := &verifier{Hash: newMultipartHash(4), name: "multipart ETag", expect: expect}
+	// write across the part boundaries
+	v.Write(data[:3])
+	v.Write(data[3:9])
+	v.Write(data[9:])
+	if err := v.verify(testBucketName, "key"); err != nil {
+		t.Errorf("multipart ETag verify failed: %s", err)
+	}
+	v.Reset()
+	v.Write(data)
+	if err := v.verify(testBucketName, "key"); err != nil {
+		t.Errorf("multipart ETag verify after Reset failed: %s", err)
+	}
+}
+
+func Test_partSizeMetadata(t *testing.T) {
+	sc := S3Cli{}
+	source := map[string]*string{"Owner": aws.String("dba"), "s3cli-part-size": aws.String("1")}
+	got := sc.partSizeMetadata(source, 16)
+	if len(got) != 2 || aws.StringValue(got["Owner"]) != "dba" || aws.StringValue(got[partSizeMetaKey]) != "16" {
+		t.Errorf("unexpected metadata: %v", got)
+	}
+	if got := sc.partSizeMetadata(source, 0); len(got) != 1 || got["Owner"] == nil {
+		t.Errorf("expect source part size dropped, got: %v", got)
+	}
+	sc.noVerify = true
+	if got := sc.partSizeMetadata(nil, 16); got != nil {
+		t.Errorf("expect no metadata with --no-verify, got: %v", got)
+	}
+}
+
+func Test_verifyBody(t *testing.T) {
+	resp := func() *s3.GetObjectOutput {
+		return &s3.GetObjectOutput{
+			Body:          ioutil.NopCloser(bytes.NewReader(testObjectContent)),
+			ContentLength: aws.Int64(int64(len(testObjectContent))),
+			ETag:          aws.String(`"0123456789abcdef0123456789abcdef"`),
+		}
+	}
+	if _, err := ioutil.ReadAll(s3cliTest.verifyBody(testBucketName, "key", resp())); err == nil {
+		t.Errorf("expect checksum mismatch error")
+	}
+
+	sc := s3cliTest
+	sc.noVerify = true
+	if _, err := ioutil.ReadAll(sc.verifyBody(testBucketName, "key", resp())); err != nil {
+		t.Errorf("verifyBody --no-verify read failed: %s", err)
+	}
+
+	r, err := s3cliTest.getObject(testBucketName, testObjectKey, "", "")
+	if err != nil {
+		t.Fatalf("getObject failed: %s", err)
+	}
+	_, err = ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Errorf("getObject verify ETag failed: %s", err)
+	}
+}
+
+func Test_putChecksum(t *testing.T) {
+	sc := s3cliTest
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	var header http.Header
+	client.Handlers.Send.PushFront(func(r *request.Request) {
+		header = r.HTTPRequest.Header.Clone()
+	})
+	sc.Client = client
+
+	// base64 digests of testObjectContent
+	md5Sum := "JlI30wbaX9xj7SINrCXEjA=="
+	cases := []struct {
+		algorithm, header, expect string
+	}{
+		{"", "", ""},
+		{checksumCRC32C, "X-Amz-Checksum-Crc32c", "Rv00Qg=="},
+		{checksumSHA256, "X-Amz-Checksum-Sha256", "GNoQP9AEm709GI+nPAPQtVKTkUv/J8tNr0PsKfb9NxI="},
+	}
+	for _, c := range cases {
+		sc.checksum = c.algorithm
+		if err := sc.putObject(testBucketName, "testPutChecksum"+c.algorithm, bytes.NewReader(testObjectContent)); err != nil {
+			t.Errorf("putObject checksum %s failed: %s", c.algorithm, err)
+			continue
+		}
+		if got := header.Get("Content-MD5"); got != md5Sum {
+			t.Errorf("checksum %s expect Content-MD5: %s, got: %s", c.algorithm, md5Sum, got)
+		}
+		for k := range header {
+			if strings.HasPrefix(k, "X-Amz-Checksum-") && k != c.header {
+				t.Errorf("checksum %s unexpected header %s", c.algorithm, k)
+			}
+		}
+		if c.header != "" && header.Get(c.header) != c.expect {
+			t.Errorf("checksum %s expect %s: %s, got: %s", c.algorithm, c.header, c.expect, header.Get(c.header))
+		}
+	}
+}
+
+func Test_putChecksumMultipart(t *testing.T) {
+	data := make([]byte, minPartSize+1024)
+	filename := filepath.Join(t.TempDir(), "file")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatalf("putChecksumMultipart WriteFile failed: %s", err)
+	}
+	sc := s3cliTest
+	sc.checksum = checksumSHA256
+	sc.partSize = minPartSize
+	sc.threshold = minPartSize
+	if err := sc.putFile(testBucketName, "testPutChecksumMultipart", filename); err == nil {
+		t.Errorf("expect putFile MPU error with checksum algorithm")
+	}
+	if err := sc.putReader(testBucketName, "testPutChecksumMultipart", bytes.NewReader(data), -1, "", nil); err == nil {
+		t.Errorf("expect putReader MPU error with checksum algorithm")
+	}
+	if err := sc.putReader(testBucketName, "testPutChecksumMultipart", bytes.NewReader(testObjectContent), -1, "", nil); err != nil {
+		t.Errorf("putReader single part with checksum algorithm failed: %s", err)
+	}
+}
+
+func Test_putMultipartETag(t *testing.T) {
+	data := make([]byte, 2*minPartSize+1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("putMultipartETag rand failed: %s", err)
+	}
+	filename := filepath.Join(t.TempDir(), "file")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatalf("putMultipartETag WriteFile failed: %s", err)
+	}
+
+	var sums []byte
+	for i := int64(0); i < int64(len(data)); i += minPartSize {
+		end := i + minPartSize
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		sum := md5.Sum(data[i:end])
+		sums = append(sums, sum[:]...)
+	}
+	sum := md5.Sum(sums)
+	etag := hex.EncodeToString(sum[:]) + "-3"
+
+	sc := s3cliTest
+	sc.partSize = minPartSize
+	sc.threshold = minPartSize
+	fileKey, readerKey := "testPutFileMultipartETag", "testPutReaderMultipartETag"
+	if err := sc.putFile(testBucketName, fileKey, filename); err != nil {
+		t.Fatalf("putFile failed: %s", err)
+	}
+	if err := sc.putReader(testBucketName, readerKey, bytes.NewReader(data), -1, "", nil); err != nil {
+		t.Fatalf("putReader failed: %s", err)
+	}
+	for _, key := range []string{fileKey, readerKey} {
+		head, err := sc.headKey(testBucketName, key)
+		if err != nil {
+			t.Fatalf("headKey failed: %s", err)
+		}
+		// gofakes3 ETags are the MD5 of the contents, verify the stored part size with a S3 multipart ETag
+		v := newVerifier(`"`+etag+`"`, head.Metadata, "", "")
+		if v == nil || v.name != "multipart ETag" {
+			t.Fatalf("%s expect part size metadata, got: %v", key, head.Metadata)
+		}
+		v.Write(data)
+		if err := v.verify(testBucketName, key); err != nil {
+			t.Errorf("%s verify multipart ETag failed: %s", key, err)
+		}
+
+		out := filepath.Join(t.TempDir(), "out")
+		if err := sc.getFile(testBucketName, key, "", out); err != nil {
+			t.Errorf("getFile verify %s failed: %s", key, err)
+		}
+		r, err := sc.getObject(testBucketName, key, "", "")
+		if err != nil {
+			t.Fatalf("getObject failed: %s", err)
+		}
+		_, err = ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Errorf("getObject verify %s failed: %s", key, err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// maxRoutingRules max routing rules of a Bucket website configuration
+	maxRoutingRules = 50
+	// errNoSuchWebsite error code of a Bucket without website configuration
+	errNoSuchWebsite = "NoSuchWebsiteConfiguration"
+	// errNoSuchBucketPolicy error code of a Bucket without policy
+	errNoSuchBucketPolicy = "NoSuchBucketPolicy"
+	// publicReadSid Sid of the public-read policy statement set by website --public
+	publicReadSid = "PublicReadGetObject"
+)
+
+// routingCondition the condition of a routing rule, all requests if empty
+type routingCondition struct {
+	KeyPrefixEquals             string `json:"keyPrefixEquals,omitempty" yaml:"keyPrefixEquals,omitempty"`
+	HTTPErrorCodeReturnedEquals string `json:"httpErrorCodeReturnedEquals,omitempty" yaml:"httpErrorCodeReturnedEquals,omitempty"`
+}
+
+// routingRedirect the redirect of a routing rule
+type routingRedirect struct {
+	HostName             string `json:"hostName,omitempty" yaml:"hostName,omitempty"`
+	HTTPRedirectCode     string `json:"httpRedirectCode,omitempty" yaml:"httpRedirectCode,omitempty"`
+	Protocol             string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	ReplaceKeyPrefixWith string `json:"replaceKeyPrefixWith,omitempty" yaml:"replaceKeyPrefixWith,omitempty"`
+	ReplaceKeyWith       string `json:"replaceKeyWith,omitempty" yaml:"replaceKeyWith,omitempty"`
+}
+
+// routingRule a readable website routing rule, also the schema of routing rules file
+type routingRule struct {
+	Condition *routingCondition `json:"condition,omitempty" yaml:"condition,omitempty"`
+	Redirect  routingRedirect   `json:"redirect" yaml:"redirect"`
+}
+
+// routingConfig the routing rules file
+type routingConfig struct {
+	Rules []routingRule `json:"rules" yaml:"rules"`
+}
+
+// loadRoutingRules read a JSON or YAML routing rules file
+func loadRoutingRules(filename string) ([]routingRule, error) {
+	cfg := routingConfig{}
+	if err := loadConfigFile(filename, &cfg, "routing rules"); err != nil {
+		return nil, err
+	}
+	return cfg.Rules, nil
+}
+
+// validRoutingRule check a routing rule
+func validRoutingRule(r routingRule) error {
+	if r.Condition != nil && r.Condition.HTTPErrorCodeReturnedEquals != "" {
+		if code, err := strconv.Atoi(r.Condition.HTTPErrorCodeReturnedEquals); err != nil || code < 400 || code > 599 {
+			return fmt.Errorf("invalid error code %s(4XX or 5XX)", r.Condition.HTTPErrorCodeReturnedEquals)
+		}
+	}
+	d := r.Redirect
+	if d == (routingRedirect{}) {
+		return errors.New("empty redirect")
+	}
+	if d.ReplaceKeyPrefixWith != "" && d.ReplaceKeyWith != "" {
+		return errors.New("replaceKeyPrefixWith and replaceKeyWith can not be used together")
+	}
+	if d.Protocol != "" && d.Protocol != s3.ProtocolHttp && d.Protocol != s3.ProtocolHttps {
+		return fmt.Errorf("invalid protocol %s(http, https)", d.Protocol)
+	}
+	if d.HTTPRedirectCode != "" {
+		if code, err := strconv.Atoi(d.HTTPRedirectCode); err != nil || code < 300 || code > 399 {
+			return fmt.Errorf("invalid redirect code %s(3XX)", d.HTTPRedirectCode)
+		}
+	}
+	return nil
+}
+
+// validWebsite check the website configuration locally before PutBucketWebsite
+func validWebsite(index string, rules []routingRule) error {
+	if index == "" {
+		return errors.New("index document(--index) is required")
+	}
+	if len(rules) > maxRoutingRules {
+		return fmt.Errorf("too many routing rules(> %d)", maxRoutingRules)
+	}
+	for i, r := range rules {
+		if err := validRoutingRule(r); err != nil {
+			return fmt.Errorf("invalid routing rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// optString return nil for an empty string
+func optString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+// toS3 convert a readable rule to s3.RoutingRule
+func (r routingRule) toS3() *s3.RoutingRule {
+	rule := &s3.RoutingRule{
+		Redirect: &s3.Redirect{
+			HostName:             optString(r.Redirect.HostName),
+			HttpRedirectCode:     optString(r.Redirect.HTTPRedirectCode),
+			Protocol:             optString(r.Redirect.Protocol),
+			ReplaceKeyPrefixWith: optString(r.Redirect.ReplaceKeyPrefixWith),
+			ReplaceKeyWith:       optString(r.Redirect.ReplaceKeyWith),
+		},
+	}
+	if r.Condition != nil {
+		rule.Condition = &s3.Condition{
+			KeyPrefixEquals:             optString(r.Condition.KeyPrefixEquals),
+			HttpErrorCodeReturnedEquals: optString(r.Condition.HTTPErrorCodeReturnedEquals),
+		}
+	}
+	return rule
+}
+
+// newRoutingRule convert a s3.RoutingRule to readable rule
+func newRoutingRule(rule *s3.RoutingRule) routingRule {
+	r := routingRule{}
+	if d := rule.Redirect; d != nil {
+		r.Redirect = routingRedirect{
+			HostName:             aws.StringValue(d.HostName),
+			HTTPRedirectCode:     aws.StringValue(d.HttpRedirectCode),
+			Protocol:             aws.StringValue(d.Protocol),
+			ReplaceKeyPrefixWith: aws.StringValue(d.ReplaceKeyPrefixWith),
+			ReplaceKeyWith:       aws.StringValue(d.ReplaceKeyWith),
+		}
+	}
+	if c := rule.Condition; c != nil {
+		r.Condition = &routingCondition{
+			KeyPrefixEquals:             aws.StringValue(c.KeyPrefixEquals),
+			HTTPErrorCodeReturnedEquals: aws.StringValue(c.HttpErrorCodeReturnedEquals),
+		}
+	}
+	return r
+}
+
+// String format a rule like: prefix=docs/ error=404 -> https://example.com/new/
+func (r routingRule) String() string {
+	s := ""
+	if c := r.Condition; c != nil {
+		if c.KeyPrefixEquals != "" {
+			s += "prefix=" + c.KeyPrefixEquals + " "
+		}
+		if c.HTTPErrorCodeReturnedEquals != "" {
+			s += "error=" + c.HTTPErrorCodeReturnedEquals + " "
+		}
+	}
+	d := r.Redirect
+	s += "->"
+	if d.HTTPRedirectCode != "" {
+		s += " " + d.HTTPRedirectCode
+	}
+	s += " "
+	if d.HostName != "" {
+		if d.Protocol != "" {
+			s += d.Protocol + "://"
+		}
+		s += d.HostName
+	}
+	switch {
+	case d.ReplaceKeyWith != "":
+		s += "/" + d.ReplaceKeyWith
+	case d.ReplaceKeyPrefixWith != "":
+		s += "/" + d.ReplaceKeyPrefixWith + "*"
+	}
+	return s
+}
+
+// publicReadStatement return the policy statement allows anyone to get Objects of bucket, required by website Buckets
+func publicReadStatement(bucket string) map[string]interface{} {
+	return map[string]interface{}{
+		"Sid":       publicReadSid,
+		"Effect":    "Allow",
+		"Principal": "*",
+		"Action":    "s3:GetObject",
+		"Resource":  fmt.Sprintf("arn:aws:s3:::%s/*", bucket),
+	}
+}
+
+// mergePublicReadPolicy append the public-read statement to the Bucket policy(empty if the Bucket has none),
+// the policy is returned unchanged(false) if it already has a statement with the same Sid
+func mergePublicReadPolicy(policy, bucket string) (string, bool, error) {
+	doc := map[string]interface{}{"Version": "2012-10-17"}
+	if policy != "" {
+		if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+			return "", false, fmt.Errorf("invalid Bucket policy: %w", err)
+		}
+	}
+	var statements []interface{}
+	switch v := doc["Statement"].(type) {
+	case nil:
+	case []interface{}:
+		statements = v
+	case map[string]interface{}:
+		statements = []interface{}{v}
+	default:
+		return "", false, errors.New("invalid Bucket policy: Statement is not an object or array")
+	}
+	for _, v := range statements {
+		if st, ok := v.(map[string]interface{}); ok && st["Sid"] == publicReadSid {
+			return policy, false, nil
+		}
+	}
+	doc["Statement"] = append(statements, publicReadStatement(bucket))
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
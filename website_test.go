@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_validWebsite(t *testing.T) {
+	valid := routingRule{
+		Condition: &routingCondition{HTTPErrorCodeReturnedEquals: "404"},
+		Redirect:  routingRedirect{HostName: "example.com", Protocol: "https", HTTPRedirectCode: "301", ReplaceKeyWith: "404.html"},
+	}
+	if err := validWebsite("index.html", []routingRule{valid}); err != nil {
+		t.Errorf("expect valid website, got: %s", err)
+	}
+	if err := validWebsite("", nil); err == nil {
+		t.Errorf("expect error without index document")
+	}
+	for _, r := range []routingRule{
+		{},
+		{Redirect: routingRedirect{ReplaceKeyWith: "a", ReplaceKeyPrefixWith: "b/"}},
+		{Redirect: routingRedirect{HostName: "example.com", Protocol: "ftp"}},
+		{Redirect: routingRedirect{HostName: "example.com", HTTPRedirectCode: "200"}},
+		{Condition: &routingCondition{HTTPErrorCodeReturnedEquals: "302"}, Redirect: routingRedirect{HostName: "example.com"}},
+	} {
+		if err := validWebsite("index.html", []routingRule{r}); err == nil {
+			t.Errorf("expect error for rule: %+v", r)
+		}
+	}
+}
+
+func Test_routingRuleS3(t *testing.T) {
+	for _, r := range []routingRule{
+		{Condition: &routingCondition{KeyPrefixEquals: "docs/"}, Redirect: routingRedirect{ReplaceKeyPrefixWith: "documents/"}},
+		{Redirect: routingRedirect{HostName: "example.com", Protocol: "https", HTTPRedirectCode: "302"}},
+	} {
+		if got := newRoutingRule(r.toS3()); !reflect.DeepEqual(got, r) {
+			t.Errorf("expect: %+v, got: %+v", r, got)
+		}
+	}
+}
+
+func Test_routingRuleString(t *testing.T) {
+	cases := map[string]routingRule{
+		"prefix=docs/ -> /documents/*": {Condition: &routingCondition{KeyPrefixEquals: "docs/"}, Redirect: routingRedirect{ReplaceKeyPrefixWith: "documents/"}},
+		"error=404 -> 302 https://example.com/404.html": {
+			Condition: &routingCondition{HTTPErrorCodeReturnedEquals: "404"},
+			Redirect:  routingRedirect{HostName: "example.com", Protocol: "https", HTTPRedirectCode: "302", ReplaceKeyWith: "404.html"},
+		},
+	}
+	for expect, r := range cases {
+		if got := r.String(); got != expect {
+			t.Errorf("expect: %s, got: %s", expect, got)
+		}
+	}
+}
+
+func Test_mergePublicReadPolicy(t *testing.T) {
+	type statement struct {
+		Sid, Effect, Action, Resource string
+	}
+	type policy struct {
+		Version   string
+		Statement []statement
+	}
+	deny := `{"Sid":"DenyDelete","Effect":"Deny","Principal":"*","Action":"s3:DeleteObject","Resource":"arn:aws:s3:::docs/*"}`
+	publicRead := statement{Sid: publicReadSid, Effect: "Allow", Action: "s3:GetObject", Resource: "arn:aws:s3:::docs/*"}
+	cases := []struct {
+		policy string
+		expect []statement
+	}{
+		{"", []statement{publicRead}},
+		{`{"Version":"2012-10-17","Statement":[` + deny + `]}`, []statement{{"DenyDelete", "Deny", "s3:DeleteObject", "arn:aws:s3:::docs/*"}, publicRead}},
+		{`{"Version":"2012-10-17","Statement":` + deny + `}`, []statement{{"DenyDelete", "Deny", "s3:DeleteObject", "arn:aws:s3:::docs/*"}, publicRead}},
+	}
+	for _, c := range cases {
+		merged, changed, err := mergePublicReadPolicy(c.policy, "docs")
+		if err != nil || !changed {
+			t.Errorf("mergePublicReadPolicy %s got: %v, %v", c.policy, changed, err)
+			continue
+		}
+		got := policy{}
+		if err := json.Unmarshal([]byte(merged), &got); err != nil {
+			t.Fatalf("invalid policy JSON: %s", err)
+		}
+		if got.Version != "2012-10-17" || !reflect.DeepEqual(got.Statement, c.expect) {
+			t.Errorf("expect statements: %+v, got: %s", c.expect, merged)
+		}
+		// the statement is added only once
+		if again, changed, err := mergePublicReadPolicy(merged, "docs"); err != nil || changed || again != merged {
+			t.Errorf("expect policy unchanged, got: %s, %v, %v", again, changed, err)
+		}
+	}
+
+	for _, p := range []string{"{", `{"Statement":"s3:GetObject"}`} {
+		if _, _, err := mergePublicReadPolicy(p, "docs"); err == nil {
+			t.Errorf("expect error for policy: %s", p)
+		}
+	}
+}
+
+func Test_bucketWebsitePresignPublic(t *testing.T) {
+	sc := s3cliTest
+	sc.presign = true
+	if err := sc.bucketWebsiteSet(testBucketName, "index.html", "", nil, true); err == nil {
+		t.Errorf("expect error for --presign with --public")
+	}
+}
+
+func Test_bucketWebsiteSetInvalid(t *testing.T) {
+	err := s3cliTest.bucketWebsiteSet(testBucketName, "index.html", "", []routingRule{{}}, true)
+	if err == nil || !strings.Contains(err.Error(), "routing rule 1") {
+		t.Errorf("expect invalid routing rule error, got: %v", err)
+	}
+}